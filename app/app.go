@@ -51,7 +51,7 @@ func New(name string, opts ...Option) (*App, error) {
 // Run initializes the application environment and starts execution of the
 // application.
 func (app *App) Run(args []string) error {
-	if err := app.cli.Parse(args); err != nil {
+	if err := app.cli.Parse(app.ctx, args); err != nil {
 		return err
 	}
 
@@ -1,7 +1,16 @@
 package app
 
 import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -81,4 +90,918 @@ func TestApp(t *testing.T) {
 | <details><summary>` + "`pkg2`" + `</summary><table><tr><td>` + "`file1.go`" + `</td><td>2.50%</td></tr><tr><td>` + "`file2.go`" + `</td><td>59.68%</td></tr></table></details>  |   37.25% |`
 		h(assert.Equal(t, expReportMd, string(reportMd)))
 	})
+
+	t.Run("ok/report_template", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		tmpl := "{{ range sortByCoverage .Packages }}{{ .Name }} {{ percent .Coverage }}\n{{ end }}" +
+			"Total: {{ percent .Total.Coverage }}"
+		err = vfs.WriteFile(app.ctx.FS, "/report.tmpl", []byte(tmpl), 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("report", "--template=/report.tmpl", "--output=tmpl:/comment.md",
+			"/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		comment, err := vfs.ReadFile(app.ctx.FS, "/comment.md")
+		require.NoError(t, err)
+		h(assert.Equal(t, "pkg2 37.25%\npkg1 72.41%\nTotal: 45.04%", string(comment)))
+	})
+
+	t.Run("ok/report_metadata", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		err = app.env.Set("SOURCE_DATE_EPOCH", "1735787045")
+		require.NoError(t, err)
+
+		err = app.Run("report", "--metadata", "--commit=0123456789abcdef",
+			"--branch=main", "--output=txt,/report.json", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Contains(t, app.stdout.String(), "Total Coverage: 45.04%\n\n"+
+			"Commit 0123456789 on branch main, created on 2025-01-02 03:04:05 UTC with fcov v"))
+
+		reportJSON, err := vfs.ReadFile(app.ctx.FS, "/report.json")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(reportJSON), `"timestamp": "2025-01-02T03:04:05Z",
+  "commit": "0123456789abcdef",
+  "branch": "main",
+  "inputs": [
+    {
+      "path": "/coverage_ok_atomic.txt",
+      "sha256": "c168cdd11f2c92f9a1d0f88222847ee728f5937d606c4681af3ce66a1e6046a4"
+    }
+  ],`))
+	})
+
+	t.Run("ok/report_ci_env", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_SERVER_URL": "https://github.com",
+			"GITHUB_REPOSITORY": "org/repo",
+			"GITHUB_SHA":        "0123456789abcdef",
+			"GITHUB_REF_NAME":   "main",
+			"GITHUB_REF_TYPE":   "branch",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("report", "--metadata", "--link-template=auto", "--no-nest-files",
+			"--output=md", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Contains(t, app.stdout.String(),
+			"| [`pkg1/file1.go`](https://github.com/org/repo/blob/0123456789abcdef/pkg1/file1.go#L16) |   60.00% |"))
+		h(assert.Contains(t, app.stdout.String(),
+			"<sub>Commit org/repo@0123456789 on branch main, created on "))
+	})
+
+	t.Run("ok/report_github_actions", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":      "true",
+			"GITHUB_STEP_SUMMARY": "/step_summary.md",
+			"GITHUB_OUTPUT":       "/output",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		// Only pkg1/file1.go is changed, so the uncovered blocks of other files
+		// are not annotated.
+		err = vfs.WriteFile(app.ctx.FS, "/pr.diff", []byte("diff --git a/pkg1/file1.go b/pkg1/file1.go\n"+
+			"--- a/pkg1/file1.go\n+++ b/pkg1/file1.go\n@@ -17 +17 @@\n-a\n+b\n"), 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("report", "--github-annotations", "--diff=/pr.diff",
+			"--output=/report.txt", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, "::warning file=pkg1/file1.go,line=16,endLine=18,title=Uncovered code::"+
+			"Lines 16-18 are not covered by tests.\n"+
+			"::warning file=pkg1/file1.go,line=32,endLine=36,title=Uncovered code::"+
+			"Lines 32-36 are not covered by tests.\n",
+			app.stdout.String()))
+
+		summary, err := vfs.ReadFile(app.ctx.FS, "/step_summary.md")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(summary), "<td>`file1.go`</td><td>60.00%</td>"))
+
+		output, err := vfs.ReadFile(app.ctx.FS, "/output")
+		require.NoError(t, err)
+		h(assert.Equal(t, "total-coverage=45.04\ntotal-statements=131\n"+
+			"covered-statements=59\nhealth=critical\n", string(output)))
+	})
+	t.Run("ok/comment", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			requests []string
+			body     map[string]string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`[{"id": 1, "body": "LGTM"},` +
+					`{"id": 2, "body": "<!-- fcov:default -->\nold report"}]`))
+				return
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			_, _ = w.Write([]byte(`{"id": 2, "html_url": "https://github.com/org/repo/pull/42#issuecomment-2"}`))
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_API_URL":    srv.URL,
+			"GITHUB_TOKEN":      "secret",
+			"GITHUB_REPOSITORY": "org/repo",
+			"GITHUB_REF":        "refs/pull/42/merge",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("comment", "--no-nest-files", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, []string{
+			"GET /repos/org/repo/issues/42/comments",
+			"PATCH /repos/org/repo/issues/comments/2",
+		}, requests))
+		h(assert.Contains(t, body["body"], "<!-- fcov:default -->\n![Total Coverage]"))
+		h(assert.Contains(t, body["body"], "| `pkg1/file1.go` |   60.00% |"))
+	})
+
+	t.Run("err/comment_no_pull_request", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("comment", "--repo=org/repo", "/coverage_ok_atomic.txt")
+		h(assert.EqualError(t, err, "unknown pull request (set it with --pull-request)"))
+	})
+	t.Run("ok/check_run", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			requests []string
+			body     map[string]any
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			if r.Method == http.MethodGet {
+				// Only pkg1/file1.go is changed by the pull request, so the
+				// uncovered blocks of other files are not annotated.
+				_, _ = w.Write([]byte(`[{"filename": "pkg1/file1.go", "status": "modified", "patch": "@@ -17 +17 @@\n-a\n+b"}]`))
+				return
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			_, _ = w.Write([]byte(`{"id": 7, "html_url": "https://github.com/org/repo/runs/7"}`))
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/event.json",
+			[]byte(`{"pull_request": {"head": {"sha": "fedcba9876543210"}}}`), 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_API_URL":    srv.URL,
+			"GITHUB_TOKEN":      "secret",
+			"GITHUB_REPOSITORY": "org/repo",
+			"GITHUB_SHA":        "0123456789abcdef",
+			"GITHUB_REF":        "refs/pull/42/merge",
+			"GITHUB_EVENT_PATH": "/event.json",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("check-run", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, []string{
+			"GET /repos/org/repo/pulls/42/files",
+			"POST /repos/org/repo/check-runs",
+		}, requests))
+		h(assert.Equal(t, "fedcba9876543210", body["head_sha"]))
+		h(assert.Equal(t, "failure", body["conclusion"]))
+		output, ok := body["output"].(map[string]any)
+		require.True(t, ok)
+		h(assert.Equal(t, "Total coverage: 45.04%", output["title"]))
+		h(assert.Equal(t, []any{
+			map[string]any{
+				"path": "pkg1/file1.go", "start_line": 16.0, "end_line": 18.0,
+				"annotation_level": "warning", "title": "Uncovered code",
+				"message": "Lines 16-18 are not covered by tests.",
+			},
+			map[string]any{
+				"path": "pkg1/file1.go", "start_line": 32.0, "end_line": 36.0,
+				"annotation_level": "warning", "title": "Uncovered code",
+				"message": "Lines 32-36 are not covered by tests.",
+			},
+		}, output["annotations"]))
+	})
+	t.Run("ok/comment_gitlab", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			requests []string
+			body     map[string]string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+			assert.Equal(t, "job-secret", r.Header.Get("JOB-TOKEN"))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITLAB_CI":            "true",
+			"CI_API_V4_URL":        srv.URL,
+			"CI_JOB_TOKEN":         "job-secret",
+			"CI_PROJECT_PATH":      "group/project",
+			"CI_MERGE_REQUEST_IID": "7",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("comment", "--marker=unit", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, []string{
+			"GET /projects/group%2Fproject/merge_requests/7/notes",
+			"POST /projects/group%2Fproject/merge_requests/7/notes",
+		}, requests))
+		h(assert.Contains(t, body["body"], "<!-- fcov:unit -->\n![Total Coverage]"))
+		h(assert.Equal(t, "Total Coverage: 45.04%\n", app.stdout.String()))
+	})
+
+	t.Run("ok/report_cobertura", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("report", "--output=txt,cobertura:/coverage.xml", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Contains(t, app.stdout.String(), "\nTotal Coverage: 45.04%\n"))
+		xml, err := vfs.ReadFile(app.ctx.FS, "/coverage.xml")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(xml),
+			`<class name="file1" filename="pkg1/file1.go" line-rate="0.5294117647058824" branch-rate="0" complexity="0">`))
+	})
+	t.Run("ok/report_openmetrics", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("report", "--output=/coverage.prom", "--branch=main",
+			"--metric-label=branch={branch},job=unit", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		prom, err := vfs.ReadFile(app.ctx.FS, "/coverage.prom")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(prom),
+			"\nfcov_coverage_ratio{scope=\"total\",branch=\"main\",job=\"unit\"} 0.45038167938931295\n"))
+		h(assert.Contains(t, string(prom),
+			"\nfcov_statements_covered{scope=\"package\",package=\"pkg1\",branch=\"main\",job=\"unit\"} "))
+		h(assert.True(t, strings.HasSuffix(string(prom), "\n# EOF\n")))
+	})
+	t.Run("err/report_metric_label", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		err = app.Run("report", "--output=openmetrics", "--metric-label=package=x", "/coverage.txt")
+		h(assert.EqualError(t, err, "report: invalid --metric-label value: metric label name 'package' is reserved"))
+
+		err = app.Run("report", "--output=openmetrics", "--metric-label=repo-name=x", "/coverage.txt")
+		h(assert.EqualError(t, err, "report: invalid --metric-label value: "+
+			"invalid metric label name 'repo-name': must match ^[a-zA-Z_][a-zA-Z0-9_]*$"))
+	})
+	t.Run("ok/otlp", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var req struct {
+			ResourceMetrics []struct {
+				Resource struct {
+					Attributes []map[string]any `json:"attributes"`
+				} `json:"resource"`
+				ScopeMetrics []struct {
+					Metrics []struct {
+						Name  string `json:"name"`
+						Gauge struct {
+							DataPoints []map[string]any `json:"dataPoints"`
+						} `json:"gauge"`
+					} `json:"metrics"`
+				} `json:"scopeMetrics"`
+			} `json:"resourceMetrics"`
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/metrics", r.URL.Path)
+			assert.Equal(t, "secret", r.Header.Get("Api-Key"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"OTEL_EXPORTER_OTLP_ENDPOINT": srv.URL,
+			"OTEL_EXPORTER_OTLP_HEADERS":  "api-key=secret",
+			"SOURCE_DATE_EPOCH":           "1735787045",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("otlp", "--repo=org/repo", "--commit=0123456789abcdef", "--branch=main",
+			"/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		require.Len(t, req.ResourceMetrics, 1)
+		rm := req.ResourceMetrics[0]
+		h(assert.Contains(t, rm.Resource.Attributes, map[string]any{
+			"key": "vcs.ref.head.revision", "value": map[string]any{"stringValue": "0123456789abcdef"},
+		}))
+		require.Len(t, rm.ScopeMetrics, 1)
+		require.Len(t, rm.ScopeMetrics[0].Metrics, 3)
+		ratio := rm.ScopeMetrics[0].Metrics[0]
+		h(assert.Equal(t, "fcov.coverage.ratio", ratio.Name))
+		// The total and 2 packages, without files.
+		require.Len(t, ratio.Gauge.DataPoints, 3)
+		h(assert.Equal(t, map[string]any{
+			"attributes":   []any{map[string]any{"key": "scope", "value": map[string]any{"stringValue": "total"}}},
+			"timeUnixNano": "1735787045000000000",
+			"asDouble":     0.45038167938931295,
+		}, ratio.Gauge.DataPoints[0]))
+		h(assert.Equal(t, "59", rm.ScopeMetrics[0].Metrics[2].Gauge.DataPoints[0]["asInt"]))
+	})
+	t.Run("ok/notify", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			token   string
+			payload map[string]any
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token = r.Header.Get("X-Token")
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/baseline.json", []byte(`{"coverage": 0.5}`), 0o644)
+		require.NoError(t, err)
+		tmpl := `{"text": {{ json (printf "Coverage on %s: %s (%+.2f%%), %s" .Metadata.Branch ` +
+			`(percent .Total.Coverage) .Delta .Health) }}}`
+		err = vfs.WriteFile(app.ctx.FS, "/payload.tmpl", []byte(tmpl), 0o644)
+		require.NoError(t, err)
+
+		args := []string{
+			"notify", "--template=/payload.tmpl", "--baseline=/baseline.json", "--branch=main",
+			"--url=" + srv.URL, "--header=X-Token=secret", "/coverage_ok_atomic.txt",
+		}
+		err = app.Run(args...)
+		require.NoError(t, err)
+		h(assert.Equal(t, "secret", token))
+		h(assert.Equal(t, map[string]any{"text": "Coverage on main: 45.04% (-4.96%), critical"}, payload))
+
+		err = app.Run(append(args, "--dry-run")...)
+		require.NoError(t, err)
+		h(assert.Equal(t, `{"text": "Coverage on main: 45.04% (-4.96%), critical"}`+"\n", app.stdout.String()))
+	})
+	t.Run("ok/history", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		for i, run := range []struct{ epoch, commit, filter string }{
+			{"1735787045", "0123456789abcdef", "pkg2/file1.go"},
+			{"1735873445", "fedcba9876543210", ""},
+		} {
+			require.NoError(t, app.env.Set("SOURCE_DATE_EPOCH", run.epoch))
+			err = app.Run("report", "--history-file=/history.jsonl", "--history-max-entries=5",
+				"--commit="+run.commit, "--branch=main", "--filter="+run.filter, "--output=/report.txt",
+				"/coverage_ok_atomic.txt")
+			require.NoErrorf(t, err, "run %d", i)
+		}
+
+		err = app.Run("history", "--output=txt,md:/trend.md,/trend.svg", "/history.jsonl")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, "Date                Commit     Coverage  Change \n"+
+			"2025-01-02 03:04:05 0123456789   63.74%         \n"+
+			"2025-01-03 03:04:05 fedcba9876   45.04% -18.70% \n\n"+
+			"Trend: █▁ 63.74% -> 45.04% (-18.70%)\n", app.stdout.String()))
+
+		trend, err := vfs.ReadFile(app.ctx.FS, "/trend.md")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(trend), "| **Total** | █▁ | 45.04% | -18.70% |\n"))
+		h(assert.Contains(t, string(trend), "| `pkg1` | ▅▅ | 72.41% | +0.00% |\n"))
+
+		svg, err := vfs.ReadFile(app.ctx.FS, "/trend.svg")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(svg), "<title>2025-01-03 03:04:05 fedcba9876: 45.04%</title>"))
+	})
+	t.Run("ok/ratchet", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("ratchet", "--file=/.fcov-ratchet", "/coverage_ok_atomic.txt")
+		h(assert.EqualError(t, err, "ratchet file /.fcov-ratchet doesn't exist: file does not exist (create it with --update)"))
+
+		err = vfs.WriteFile(app.ctx.FS, "/.fcov-ratchet", []byte("pkg1 80\n"), 0o644)
+		require.NoError(t, err)
+
+		// Minimums are never lowered, and new packages are added.
+		err = app.Run("ratchet", "--file=/.fcov-ratchet", "--update", "/coverage_ok_atomic.txt")
+		h(assert.EqualError(t, err, "coverage of 1 package(s) dropped below the minimum "+
+			"(add tests, or lower the minimums in /.fcov-ratchet by hand)"))
+		require.NoError(t, app.flushOutputs())
+		h(assert.Equal(t, "pkg1: 72.41% is below the minimum of 80.00% (-7.59%)\n", app.stdout.String()))
+
+		ratchetData, err := vfs.ReadFile(app.ctx.FS, "/.fcov-ratchet")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(ratchetData), "pkg1   80.00\npkg2   37.25\n"))
+
+		// Packages without a minimum pass, unless --strict is set.
+		err = vfs.WriteFile(app.ctx.FS, "/.fcov-ratchet", []byte("pkg1 72.41\n"), 0o644)
+		require.NoError(t, err)
+		err = app.Run("ratchet", "--file=/.fcov-ratchet", "/coverage_ok_atomic.txt")
+		h(assert.NoError(t, err))
+		require.NoError(t, app.flushOutputs())
+		h(assert.Equal(t, "", app.stdout.String()))
+
+		err = app.Run("ratchet", "--file=/.fcov-ratchet", "--strict", "/coverage_ok_atomic.txt")
+		h(assert.EqualError(t, err, "1 package(s) don't have a minimum coverage "+
+			"(add them to /.fcov-ratchet with --update)"))
+		require.NoError(t, app.flushOutputs())
+		h(assert.Equal(t, "pkg2: 37.25% has no minimum coverage\n", app.stdout.String()))
+
+		err = vfs.WriteFile(app.ctx.FS, "/.fcov-ratchet", []byte("pkg1 72.41\npkg2 37.25\n"), 0o644)
+		require.NoError(t, err)
+		err = app.Run("ratchet", "--file=/.fcov-ratchet", "/coverage_ok_atomic.txt")
+		h(assert.NoError(t, err))
+	})
+	t.Run("ok/config", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		config := "filter: [pkg2/file1.go]\nthresholds: [65, 90]\n" +
+			"report:\n  output: [json, /report.md]\n  github-actions: false\n"
+		err = vfs.WriteFile(app.ctx.FS, "/.fcov.yaml", []byte(config), 0o644)
+		require.NoError(t, err)
+
+		// Flags take precedence over the configuration file.
+		err = app.Run("report", "--output=/report.md", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		h(assert.Empty(t, app.stdout.String()))
+
+		md, err := vfs.ReadFile(app.ctx.FS, "/report.md")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(md), "63.74%"))
+		h(assert.Contains(t, string(md), "Coverage-63.74%25-critical"))
+	})
+	t.Run("ok/threshold_rules", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		err = app.Run("report", "--output=junit", "--no-github-actions",
+			"--threshold-rule=pkg1/file2.go=0,10;pkg1=80,90", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		out := app.stdout.String()
+		h(assert.Contains(t, out, `<testcase classname="packages" name="pkg1" time="0">`+"\n"+
+			`      <failure message="coverage 72.41% is below the minimum of 80.00%"`))
+		h(assert.Contains(t, out, `<testcase classname="pkg1" name="file1.go" time="0">`+"\n"+
+			`      <failure message="coverage 60.00% is below the minimum of 80.00%"`))
+		h(assert.Contains(t, out, `<testcase classname="pkg1" name="file2.go" time="0">`+"\n"+
+			`      <system-out>`))
+		h(assert.Contains(t, out, `<testcase classname="pkg2" name="file1.go" time="0">`+"\n"+
+			`      <failure message="coverage 2.50% is below the minimum of 50.00%"`))
+	})
+	t.Run("ok/report_health_indicators", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		// Simulate stdout being a terminal.
+		app, err := newTestApp(tctx, WithLogger(true, false))
+		h(assert.NoError(t, err))
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+
+		// Health indicators are opt-in.
+		err = app.Run("report", "--no-github-actions", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		h(assert.NotContains(t, app.stdout.String(), "\x1b["))
+
+		err = app.Run("report", "--health-indicators", "--output=txt,/report.txt,/report.md",
+			"--no-github-actions", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		h(assert.Contains(t, app.stdout.String(), "    file1.go \x1b[33m60.00%\x1b[0m \n"))
+		h(assert.Contains(t, app.stdout.String(), "\x1b[31mTotal Coverage: 45.04%\x1b[0m"))
+
+		// Files are never colored.
+		reportTxt, err := vfs.ReadFile(app.ctx.FS, "/report.txt")
+		require.NoError(t, err)
+		h(assert.NotContains(t, string(reportTxt), "\x1b["))
+
+		reportMd, err := vfs.ReadFile(app.ctx.FS, "/report.md")
+		require.NoError(t, err)
+		h(assert.Contains(t, string(reportMd), "<td>`file1.go`</td><td>🟡 60.00%</td>"))
+		h(assert.Contains(t, string(reportMd), "| 🔴 37.25% |"))
+
+		require.NoError(t, app.env.Set("NO_COLOR", "1"))
+		err = app.Run("report", "--health-indicators", "--no-github-actions", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		h(assert.NotContains(t, app.stdout.String(), "\x1b["))
+		h(assert.Contains(t, app.stdout.String(), "    file1.go 60.00% \n"))
+	})
+	t.Run("ok/insights", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			requests    []string
+			report      map[string]any
+			annotations map[string][]map[string]any
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			switch r.Method {
+			case http.MethodGet:
+				// Only the uncovered lines changed by the pull request are
+				// annotated. Line 40 of pkg1/file2.go is covered.
+				_, _ = w.Write([]byte("diff --git src://pkg1/file1.go dst://pkg1/file1.go\n" +
+					"--- src://pkg1/file1.go\n+++ dst://pkg1/file1.go\n" +
+					"@@ -17 +17 @@\n-a\n+b\n@@ -34,0 +34,2 @@\n+c\n+d\n" +
+					"diff --git src://pkg1/file2.go dst://pkg1/file2.go\n" +
+					"--- src://pkg1/file2.go\n+++ dst://pkg1/file2.go\n@@ -40 +40 @@\n-e\n+f\n"))
+				return
+			case http.MethodPut:
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+			case http.MethodPost:
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&annotations))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"BITBUCKET_TOKEN": "secret",
+			// The repository is detected from the Data Center clone URL.
+			"JENKINS_URL": "https://jenkins.example.com",
+			"GIT_URL":     "https://bitbucket.example.com/scm/PROJ/repo.git",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("insights", "--api-url", srv.URL, "--commit=0123456789abcdef",
+			"--pull-request=42", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		reportPath := "/rest/insights/1.0/projects/PROJ/repos/repo/commits/0123456789abcdef/reports/fcov"
+		h(assert.Equal(t, []string{
+			"GET /rest/api/1.0/projects/PROJ/repos/repo/pull-requests/42.diff",
+			"PUT " + reportPath,
+			"DELETE " + reportPath + "/annotations",
+			"POST " + reportPath + "/annotations",
+		}, requests))
+		h(assert.Equal(t, map[string]any{
+			"title":    "Coverage",
+			"details":  "45.04% of statements are covered by tests.",
+			"result":   "FAIL",
+			"reporter": "fcov",
+			"data": []any{
+				map[string]any{"title": "Total coverage", "type": "PERCENTAGE", "value": 45.04},
+				map[string]any{"title": "Covered statements", "type": "NUMBER", "value": 59.0},
+				map[string]any{"title": "Total statements", "type": "NUMBER", "value": 131.0},
+				map[string]any{"title": "Health", "type": "TEXT", "value": "critical"},
+			},
+		}, report))
+		h(assert.Equal(t, []map[string]any{
+			{
+				"path": "pkg1/file1.go", "line": 17.0, "severity": "LOW", "type": "CODE_SMELL",
+				"message": "Line 17 is not covered by tests.",
+			},
+			{
+				"path": "pkg1/file1.go", "line": 34.0, "severity": "LOW", "type": "CODE_SMELL",
+				"message": "Lines 34-35 are not covered by tests.",
+			},
+		}, annotations["annotations"]))
+	})
+	t.Run("ok/upload", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			query   url.Values
+			payload []byte
+		)
+		mux := http.NewServeMux()
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		mux.HandleFunc("POST /upload/v4", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+			query = r.URL.Query()
+			_, _ = w.Write([]byte("https://codecov.example.com/results/1\n" + srv.URL + "/store/1\n"))
+		})
+		mux.HandleFunc("PUT /store/1", func(_ http.ResponseWriter, r *http.Request) {
+			zr, err := gzip.NewReader(r.Body)
+			assert.NoError(t, err)
+			payload, err = io.ReadAll(zr)
+			assert.NoError(t, err)
+		})
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		require.NoError(t, app.ctx.FS.MkdirAll("/repo/pkg1", 0o755))
+		err = vfs.WriteFile(app.ctx.FS, "/repo/pkg1/file1.go", []byte("package pkg1"), 0o644)
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_REPOSITORY": "org/repo",
+			"GITHUB_SHA":        "0123456789abcdef",
+			"GITHUB_REF":        "refs/pull/42/merge",
+			"GITHUB_HEAD_REF":   "feature",
+			"CODECOV_URL":       srv.URL,
+			"CODECOV_TOKEN":     "secret",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("upload", "--flags=unit", "--root=/repo", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		h(assert.Equal(t, "org/repo", query.Get("slug")))
+		h(assert.Equal(t, "0123456789abcdef", query.Get("commit")))
+		h(assert.Equal(t, "feature", query.Get("branch")))
+		h(assert.Equal(t, "42", query.Get("pr")))
+		h(assert.Equal(t, "unit", query.Get("flags")))
+		h(assert.Equal(t, "github-actions", query.Get("service")))
+		h(assert.Equal(t, "pkg1/file1.go\n<<<<<< network\n# path=/coverage_ok_atomic.txt\n"+
+			string(covData)+"<<<<<< EOF\n", string(payload)))
+	})
+	t.Run("ok/coveralls", func(t *testing.T) {
+		t.Parallel()
+
+		tctx, cancel, h := newTestContext(t, 5*time.Second)
+		defer cancel()
+		app, err := newTestApp(tctx)
+		h(assert.NoError(t, err))
+
+		var (
+			job  map[string]any
+			done map[string]any
+		)
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+			file, _, err := r.FormFile("json_file")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer file.Close()
+			assert.NoError(t, json.NewDecoder(file).Decode(&job))
+			_, _ = w.Write([]byte(`{"message": "Job #7.1", "url": "https://coveralls.example.com/jobs/1"}`))
+		})
+		mux.HandleFunc("POST /webhook", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "secret", r.URL.Query().Get("repo_token"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&done))
+			_, _ = w.Write([]byte(`{"done": true}`))
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		covData, err := os.ReadFile("testdata/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+		src := []byte(strings.Repeat("\n", 40))
+		require.NoError(t, app.ctx.FS.MkdirAll("/repo/pkg1", 0o755))
+		err = vfs.WriteFile(app.ctx.FS, "/repo/pkg1/file1.go", src, 0o644)
+		require.NoError(t, err)
+		err = vfs.WriteFile(app.ctx.FS, "/coverage_ok_atomic.txt", covData, 0o644)
+		require.NoError(t, err)
+		for k, v := range map[string]string{
+			"GITHUB_ACTIONS":       "true",
+			"GITHUB_SERVER_URL":    "https://github.com",
+			"GITHUB_REPOSITORY":    "org/repo",
+			"GITHUB_SHA":           "0123456789abcdef",
+			"GITHUB_REF":           "refs/pull/42/merge",
+			"GITHUB_HEAD_REF":      "feature",
+			"GITHUB_RUN_ID":        "123",
+			"GITHUB_RUN_NUMBER":    "7",
+			"COVERALLS_ENDPOINT":   srv.URL,
+			"COVERALLS_REPO_TOKEN": "secret",
+		} {
+			require.NoError(t, app.env.Set(k, v))
+		}
+
+		err = app.Run("coveralls", "--filter=*,!pkg1/file1.go", "--root=/repo",
+			"--parallel", "--flag-name=unit", "/coverage_ok_atomic.txt")
+		require.NoError(t, err)
+
+		coverage := make([]any, 40)
+		for line := 16; line <= 36; line++ {
+			switch {
+			case line <= 18, line >= 32:
+				coverage[line-1] = 0.0
+			case line >= 22 && line <= 30:
+				coverage[line-1] = 1.0
+			}
+		}
+		digest := md5.Sum(src) //nolint:gosec // Coveralls uses MD5 digests.
+		h(assert.Equal(t, map[string]any{
+			"repo_token":           "secret",
+			"service_name":         "github",
+			"service_job_id":       "123",
+			"service_number":       "7",
+			"service_pull_request": "42",
+			"service_job_url":      "https://github.com/org/repo/actions/runs/123",
+			"parallel":             true,
+			"flag_name":            "unit",
+			"commit_sha":           "0123456789abcdef",
+			"git": map[string]any{
+				"head": map[string]any{"id": "0123456789abcdef"}, "branch": "feature",
+			},
+			"source_files": []any{map[string]any{
+				"name":          "pkg1/file1.go",
+				"source_digest": hex.EncodeToString(digest[:]),
+				"coverage":      coverage,
+			}},
+		}, job))
+
+		err = app.Run("coveralls", "--done")
+		require.NoError(t, err)
+		h(assert.Equal(t, map[string]any{
+			"repo_token": "secret",
+			"payload":    map[string]any{"build_num": "7", "status": "done"},
+		}, done))
+	})
 }
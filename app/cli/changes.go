@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/diff"
+	"go.hackfix.me/fcov/report"
+	"go.hackfix.me/fcov/types"
+)
+
+// ChangesFlags are the flags of commands that annotate the uncovered code
+// changed by a pull request.
+type ChangesFlags struct {
+	Diff        string `help:"Path to a unified diff of the pull request changes, e.g. created with 'git diff origin/main...HEAD'. Only uncovered code in the changed files is annotated. If not set, the changes are fetched from the code host API. " placeholder:"<path>"`
+	PullRequest int    `help:"Number of the pull request whose changed files are annotated, if --diff is not set. Detected from the CI environment if not set. " placeholder:"<number>"`
+}
+
+// changes returns the changes of the pull request, read from the diff file,
+// or fetched with fetch if the pull request is known. It returns nil if the
+// changes are unknown.
+func (f ChangesFlags) changes(
+	appCtx *actx.Context, fetch func(pr int) (diff.Changes, error),
+) (diff.Changes, error) {
+	if f.Diff != "" {
+		file, err := appCtx.FS.Open(f.Diff)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening diff file: %w", err)
+		}
+		defer file.Close()
+
+		changes, err := diff.Parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading diff file: %w", err)
+		}
+		return changes, nil
+	}
+
+	pr := f.PullRequest
+	if pr == 0 {
+		ciInfo, _ := detectCI(appCtx)
+		pr = ciInfo.PullRequest
+	}
+	if pr == 0 {
+		return nil, nil //nolint:nilnil // Unknown changes are not an error.
+	}
+
+	return fetch(pr)
+}
+
+// uncoveredChanges returns the merged uncovered blocks of the report in the
+// changed files. If lines is true, the blocks are also clipped to the changed
+// lines, and blocks without changed lines are omitted. If changes is nil, no
+// blocks are returned, and a warning is logged.
+func uncoveredChanges(
+	appCtx *actx.Context, sum *report.Report, opts report.RenderOptions,
+	changes diff.Changes, lines bool,
+) []report.Uncovered {
+	if changes == nil {
+		appCtx.Logger.Warn("unknown pull request changes, uncovered code will not be annotated",
+			"hint", "set --diff or --pull-request")
+		return nil
+	}
+
+	var blocks []report.Uncovered
+	for _, b := range report.MergeAdjacent(sum.UncoveredBlocks(opts)) {
+		if !changes.Changed(b.Path) {
+			continue
+		}
+		if !lines {
+			blocks = append(blocks, b)
+			continue
+		}
+		for _, r := range changes.Intersect(b.Path, b.Start.Line, b.End.Line) {
+			clipped := b
+			if r.Start != b.Start.Line {
+				clipped.Start = types.FileLocation{Line: r.Start}
+			}
+			if r.End != b.End.Line {
+				clipped.End = types.FileLocation{Line: r.End}
+			}
+			blocks = append(blocks, clipped)
+		}
+	}
+
+	return blocks
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/publish/github"
+	"go.hackfix.me/fcov/report"
+)
+
+// githubCheckSummaryMaxSize is the maximum amount of characters of the
+// summary of a GitHub check run.
+const githubCheckSummaryMaxSize = 65535
+
+// CheckRun is the fcov check-run command.
+type CheckRun struct {
+	ReportFlags  `embed:""`
+	GitHubFlags  `embed:""`
+	ChangesFlags `embed:""`
+
+	Name    string `help:"Name of the check run. " default:"fcov" placeholder:"<name>"`
+	HeadSha string `help:"Commit SHA to create the check run on. Defaults to the head commit of the pull request that triggered the GitHub Actions workflow, or the report commit. " placeholder:"<sha>"`
+}
+
+// Run the fcov check-run command.
+func (s *CheckRun) Run(appCtx *actx.Context) error {
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
+
+	repo := sum.Metadata.Repository
+	if repo == "" {
+		return aerrors.NewRuntimeError("unknown repository", nil, "set it with --repo")
+	}
+
+	headSHA, err := s.headSHA(appCtx, sum.Metadata.Commit)
+	if err != nil {
+		return err
+	}
+	if headSHA == "" {
+		return aerrors.NewRuntimeError("unknown commit", nil, "set it with --head-sha")
+	}
+
+	if renderOpts.MaxSize == 0 {
+		renderOpts.MaxSize = githubCheckSummaryMaxSize
+	}
+	summary, err := sum.Render(report.Markdown, renderOpts)
+	if err != nil {
+		return fmt.Errorf("failed rendering %s report: %w", report.Markdown, err)
+	}
+
+	client := s.client(appCtx)
+	changes, err := s.changes(appCtx, githubChanges(appCtx, client, repo))
+	if err != nil {
+		return err
+	}
+	blocks := uncoveredChanges(appCtx, sum, renderOpts, changes, false)
+	annotations := make([]github.CheckAnnotation, 0, len(blocks))
+	for _, b := range blocks {
+		annotations = append(annotations, github.CheckAnnotation{
+			Path: b.Path, StartLine: b.Start.Line, EndLine: b.End.Line,
+			Level: "warning", Title: uncoveredTitle, Message: uncoveredMessage(b),
+		})
+	}
+	if len(annotations) > github.MaxCheckAnnotations {
+		appCtx.Logger.Warn("too many uncovered blocks, only some will be annotated",
+			"blocks", len(annotations), "max", github.MaxCheckAnnotations)
+	}
+
+	pct := sum.Coverage * 100
+	run, err := client.CreateCheckRun(appCtx.Ctx, repo, github.CheckRunOptions{
+		Name:        s.Name,
+		HeadSHA:     headSHA,
+		Conclusion:  checkConclusion(renderOpts.Thresholds.Health(pct)),
+		Title:       fmt.Sprintf("Total coverage: %.2f%%", pct),
+		Summary:     summary,
+		Annotations: annotations,
+	})
+	if err != nil {
+		return fmt.Errorf("failed publishing check run: %w", err)
+	}
+	appCtx.Logger.Info("created check run", "url", run.HTMLURL,
+		"annotations", min(len(annotations), github.MaxCheckAnnotations))
+
+	return nil
+}
+
+// headSHA returns the commit SHA the check run should be created on.
+func (s *CheckRun) headSHA(appCtx *actx.Context, commit string) (string, error) {
+	if s.HeadSha != "" {
+		return s.HeadSha, nil
+	}
+
+	if github.InActions(appCtx.Env) {
+		sha, err := github.EventHeadSHA(appCtx.FS, appCtx.Env)
+		if err != nil {
+			return "", fmt.Errorf("failed detecting the pull request head commit: %w", err)
+		}
+		if sha != "" {
+			return sha, nil
+		}
+	}
+
+	return commit, nil
+}
+
+// checkConclusion returns the check run conclusion for the coverage health.
+func checkConclusion(h report.Health) github.Conclusion {
+	switch h {
+	case report.HealthCritical:
+		return github.ConclusionFailure
+	case report.HealthWarning:
+		return github.ConclusionNeutral
+	default:
+		return github.ConclusionSuccess
+	}
+}
@@ -11,10 +11,20 @@ import (
 
 // CLI is the command line interface of fcov.
 type CLI struct {
-	kong *kong.Kong
-	kctx *kong.Context
+	kong   *kong.Kong
+	kctx   *kong.Context
+	config *config
 
-	Report Report `kong:"cmd,help='Analyze coverage file(s) and create a coverage report.'"`
+	Report    Report    `kong:"cmd,help='Analyze coverage file(s) and create a coverage report.'"`
+	Comment   Comment   `kong:"cmd,help='Post the coverage report as a pull request comment.'"`
+	CheckRun  CheckRun  `kong:"cmd,help='Create a GitHub check run with the coverage report and annotations of uncovered lines.'"`
+	Insights  Insights  `kong:"cmd,help='Publish a Bitbucket Code Insights report with annotations of uncovered lines.'"`
+	Upload    Upload    `kong:"cmd,help='Upload coverage files to a Codecov-compatible server.'"`
+	Coveralls Coveralls `kong:"cmd,help='Send coverage to a Coveralls server.'"`
+	OTLP      OTLP      `kong:"cmd,name='otlp',help='Export coverage metrics to an OpenTelemetry collector over OTLP/HTTP.'"`
+	Notify    Notify    `kong:"cmd,help='Send a JSON payload rendered from a template to a webhook.'"`
+	History   History   `kong:"cmd,help='Show coverage trends from a history file.'"`
+	Ratchet   Ratchet   `kong:"cmd,help='Check that the coverage of each package is not below its minimum in a ratchet file.'"`
 
 	Log struct {
 		Level slog.Level `enum:"DEBUG,INFO,WARN,ERROR" default:"INFO" help:"Set the app logging level."`
@@ -24,11 +34,12 @@ type CLI struct {
 
 // New initializes the command-line interface.
 func New(version string) (*CLI, error) {
-	c := &CLI{}
+	c := &CLI{config: &config{}}
 	kparser, err := kong.New(c,
 		kong.Name("fcov"),
 		kong.UsageOnError(),
 		kong.DefaultEnvars("FCOV"),
+		kong.Resolvers(c.config),
 		kong.ConfigureHelp(kong.HelpOptions{
 			Compact:             true,
 			Summary:             true,
@@ -54,13 +65,22 @@ func (c *CLI) Execute(appCtx *actx.Context) error {
 	}
 	c.kong.Stdout = appCtx.Stdout
 	c.kong.Stderr = appCtx.Stderr
+	if c.config.path != "" {
+		appCtx.Logger.Debug("loaded config file", "path", c.config.path)
+	}
 
 	return c.kctx.Run(appCtx)
 }
 
-// Parse the given command line arguments. This method must be called before
-// Execute.
-func (c *CLI) Parse(args []string) error {
+// Parse the given command line arguments. Flags that are not provided as
+// arguments or environment variables are read from the configuration file in
+// the working directory or its parents, if any. This method must be called
+// before Execute.
+func (c *CLI) Parse(appCtx *actx.Context, args []string) error {
+	if err := c.config.load(appCtx.FS); err != nil {
+		return err
+	}
+
 	kctx, err := c.kong.Parse(args)
 	if err != nil {
 		return err
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/ci"
+	"go.hackfix.me/fcov/publish"
+	"go.hackfix.me/fcov/publish/github"
+	"go.hackfix.me/fcov/publish/gitlab"
+	"go.hackfix.me/fcov/report"
+)
+
+// Maximum amount of characters of comments on each code host.
+const (
+	githubCommentMaxSize = 65536
+	gitlabNoteMaxSize    = 1000000
+)
+
+// Comment is the fcov comment command.
+type Comment struct {
+	ReportFlags `embed:""`
+
+	Provider    string `help:"Code host to post the comment to. If 'auto', GitLab is used when running in GitLab CI, and GitHub otherwise. " enum:"auto,github,gitlab" default:"auto"`
+	APIURL      string `name:"api-url" help:"Base URL of the GitHub or GitLab API. Defaults to the GITHUB_API_URL or CI_API_V4_URL environment variable, or the API of github.com or gitlab.com. " placeholder:"<url>"`
+	Token       string `help:"Token used to authenticate with the API. Defaults to the GITHUB_TOKEN environment variable for GitHub, and GITLAB_TOKEN or CI_JOB_TOKEN for GitLab. " placeholder:"<token>"`
+	PullRequest int    `help:"Number of the pull or merge request to comment on. Detected from the CI environment if not set. " placeholder:"<number>"`
+	Marker      string `help:"ID of the hidden marker used to find and update the comment previously created by fcov. Use different values to post separate comments for different reports on the same pull request. " default:"default" placeholder:"<id>"`
+}
+
+// Run the fcov comment command.
+func (s *Comment) Run(appCtx *actx.Context) error {
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
+
+	ciInfo, _ := detectCI(appCtx)
+	provider := s.Provider
+	if provider == "auto" {
+		provider = "github"
+		if ciInfo.Provider == ci.GitLab {
+			provider = "gitlab"
+		}
+	}
+
+	pr := s.PullRequest
+	if pr == 0 {
+		pr = ciInfo.PullRequest
+	}
+	if pr == 0 {
+		return aerrors.NewRuntimeError("unknown pull request", nil,
+			"set it with --pull-request")
+	}
+
+	repo := sum.Metadata.Repository
+	if repo == "" {
+		return aerrors.NewRuntimeError("unknown repository", nil, "set it with --repo")
+	}
+
+	marker := publish.Marker(s.Marker)
+	if renderOpts.MaxSize == 0 {
+		renderOpts.MaxSize = githubCommentMaxSize - len(marker) - 1
+		if provider == "gitlab" {
+			renderOpts.MaxSize = gitlabNoteMaxSize - len(marker) - 1
+		}
+	}
+
+	format := report.Markdown
+	if renderOpts.Template != "" {
+		format = report.Template
+	}
+	body, err := sum.Render(format, renderOpts)
+	if err != nil {
+		return fmt.Errorf("failed rendering %s report: %w", format, err)
+	}
+
+	var created bool
+	if provider == "gitlab" {
+		var note *gitlab.Note
+		note, created, err = s.gitlabClient(appCtx).UpsertNote(appCtx.Ctx, repo, pr, marker, body)
+		if err != nil {
+			return fmt.Errorf("failed commenting on merge request !%d: %w", pr, err)
+		}
+		appCtx.Logger.Info(upsertAction(created)+" merge request note", "id", note.ID)
+	} else {
+		var comment *github.Comment
+		comment, created, err = newGitHubClient(appCtx, s.APIURL, s.Token).
+			UpsertComment(appCtx.Ctx, repo, pr, marker, body)
+		if err != nil {
+			return fmt.Errorf("failed commenting on pull request #%d: %w", pr, err)
+		}
+		appCtx.Logger.Info(upsertAction(created)+" pull request comment", "url", comment.HTMLURL)
+	}
+
+	// Also print the total coverage, so that it can be parsed from the job log,
+	// e.g. by GitLab's coverage regex.
+	if _, err = fmt.Fprintln(appCtx.Stdout, report.TotalCoverageLine(sum.Coverage)); err != nil {
+		return fmt.Errorf("failed writing total coverage: %w", err)
+	}
+
+	return nil
+}
+
+// gitlabClient returns a GitLab API client configured from the flags, or the
+// environment variables set by GitLab CI.
+func (s *Comment) gitlabClient(appCtx *actx.Context) *gitlab.Client {
+	apiURL := envDefault(appCtx, s.APIURL, "CI_API_V4_URL")
+	token, tokenType := envDefault(appCtx, s.Token, "GITLAB_TOKEN"), gitlab.PrivateToken
+	if token == "" {
+		token, tokenType = envDefault(appCtx, "", "CI_JOB_TOKEN"), gitlab.JobToken
+	}
+
+	return gitlab.NewClient(apiURL, token, tokenType, nil)
+}
+
+func upsertAction(created bool) string {
+	if created {
+		return "created"
+	}
+
+	return "updated"
+}
+
+// envDefault returns value, or the value of the environment variable envVar
+// if value is empty.
+func envDefault(appCtx *actx.Context, value, envVar string) string {
+	if value != "" || appCtx.Env == nil {
+		return value
+	}
+
+	return appCtx.Env.Get(envVar)
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames returns the names of the configuration file, in order of
+// precedence.
+func configFileNames() []string {
+	return []string{".fcov.yaml", ".fcov.yml", ".fcov.toml"}
+}
+
+// config is a kong.Resolver that sets the values of flags that were not
+// provided on the command line or in environment variables from a
+// configuration file.
+//
+// Top-level keys of the file are flag names, whose values are used by all
+// commands that have the flag. Keys that are command names contain the flag
+// values of that command, which take precedence over the top-level values.
+type config struct {
+	// path is the path of the loaded configuration file. It's empty if no file
+	// was found.
+	path   string
+	values map[string]any
+}
+
+var _ kong.Resolver = &config{}
+
+// load finds the configuration file in the working directory or its parents,
+// and reads it. Previously loaded values are discarded.
+func (c *config) load(fsys vfs.FileSystem) error {
+	c.path, c.values = "", nil
+
+	path, err := findConfig(fsys)
+	if err != nil || path == "" {
+		return err
+	}
+
+	data, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return fmt.Errorf("failed reading config file: %w", err)
+	}
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &c.values)
+	} else {
+		err = yaml.Unmarshal(data, &c.values)
+	}
+	if err != nil {
+		return fmt.Errorf("failed decoding config file %s: %w", path, err)
+	}
+	c.path = path
+
+	return nil
+}
+
+// findConfig returns the path of the configuration file in the working
+// directory, or the closest of its parents. It returns an empty path if no
+// file is found.
+func findConfig(fsys vfs.FileSystem) (string, error) {
+	dir, err := fsys.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed getting the working directory: %w", err)
+	}
+
+	for {
+		for _, name := range configFileNames() {
+			path := filepath.Join(dir, name)
+			_, err = fsys.Stat(path)
+			if err == nil {
+				return path, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("failed reading config file: %w", err)
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Validate implements the kong.Resolver interface. It returns an error if the
+// configuration contains a key that is not a flag or a command.
+func (c *config) Validate(app *kong.Application) error {
+	if len(c.values) == 0 {
+		return nil
+	}
+
+	// Flags of the application are accepted in all command sections.
+	appFlags := map[string]bool{}
+	for _, flag := range app.Flags {
+		appFlags[flag.Name] = true
+	}
+	allFlags := maps.Clone(appFlags)
+	cmdFlags := map[string]map[string]bool{}
+	for _, cmd := range app.Leaves(false) {
+		flags := maps.Clone(appFlags)
+		for _, flag := range cmd.Flags {
+			flags[flag.Name], allFlags[flag.Name] = true, true
+		}
+		cmdFlags[cmd.Name] = flags
+	}
+
+	for _, key := range sortedKeys(c.values) {
+		if section, ok := c.values[key].(map[string]any); ok && cmdFlags[key] != nil {
+			for _, flag := range sortedKeys(section) {
+				if !cmdFlags[key][flag] {
+					return fmt.Errorf("%s: unknown flag '%s' of command '%s'", c.path, flag, key)
+				}
+			}
+			continue
+		}
+		if !allFlags[key] {
+			return fmt.Errorf("%s: unknown flag or command '%s'", c.path, key)
+		}
+	}
+
+	return nil
+}
+
+// Resolve implements the kong.Resolver interface.
+func (c *config) Resolve(kctx *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+	if len(c.values) == 0 {
+		return nil, nil //nolint:nilnil // No value is a valid result.
+	}
+
+	// Environment variables take precedence over the configuration file. kong
+	// applies them before the resolvers, but doesn't skip the resolvers.
+	for _, env := range flag.Envs {
+		if _, ok := os.LookupEnv(env); ok {
+			return nil, nil //nolint:nilnil // No value is a valid result.
+		}
+	}
+
+	value, ok := c.values[flag.Name]
+	if cmd := kctx.Selected(); cmd != nil {
+		if section, isMap := c.values[cmd.Name].(map[string]any); isMap {
+			if v, found := section[flag.Name]; found {
+				value, ok = v, true
+			}
+		}
+	}
+	if !ok || value == nil {
+		return nil, nil //nolint:nilnil // No value is a valid result.
+	}
+
+	sep := ","
+	if flag.Target.Kind() == reflect.Map && flag.Tag.MapSep > 0 {
+		sep = string(flag.Tag.MapSep)
+	} else if flag.Target.Kind() == reflect.Slice && flag.Tag.Sep > 0 {
+		sep = string(flag.Tag.Sep)
+	}
+
+	return configValue(value, sep), nil
+}
+
+// configValue converts a value decoded from the configuration file into the
+// string form of the flag on the command line. Lists are joined with sep, and
+// maps are converted to '<key>=<value>' pairs joined with sep.
+func configValue(value any, sep string) string {
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = configValue(item, ",")
+		}
+		return strings.Join(items, sep)
+	case map[string]any:
+		items := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			items = append(items, key+"="+configValue(v[key], ","))
+		}
+		return strings.Join(items, sep)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
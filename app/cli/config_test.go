@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configTestCLI struct {
+	Report struct {
+		Files      []string          `arg:""`
+		Filter     []string          `env:"FCOV_CONFIG_TEST_FILTER"`
+		Thresholds ThresholdsOption  `default:"50,75"`
+		Label      map[string]string `mapsep:","`
+		Max        int
+		Rule       []string `sep:";"`
+		Nest       bool     `default:"true" negatable:""`
+	} `cmd:""`
+	Other struct {
+		Filter []string
+	} `cmd:""`
+}
+
+func newConfigTestParser(t *testing.T, fsys vfs.FileSystem) (*configTestCLI, *kong.Kong, *config) {
+	t.Helper()
+
+	cfg := &config{}
+	require.NoError(t, cfg.load(fsys))
+	cli := &configTestCLI{}
+	parser, err := kong.New(cli, kong.Resolvers(cfg))
+	require.NoError(t, err)
+
+	return cli, parser, cfg
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		config string
+		// toml is the same configuration as config, in TOML.
+		toml   string
+		args   []string
+		check  func(t *testing.T, cli *configTestCLI)
+		expErr string
+	}{
+		{
+			name: "ok/values",
+			config: "filter: ['*', '!pkg1/']\nthresholds: [60, 80.5]\nlabel: {repo: fcov, n: 1}\n" +
+				"max: 5\nnest: false\n",
+			toml: "filter = ['*', '!pkg1/']\nthresholds = [60, 80.5]\nlabel = {repo = 'fcov', n = 1}\n" +
+				"max = 5\nnest = false\n",
+			args: []string{"report", "cov.txt"},
+			check: func(t *testing.T, cli *configTestCLI) {
+				t.Helper()
+				assert.Equal(t, []string{"*", "!pkg1/"}, cli.Report.Filter)
+				assert.InDelta(t, 60, cli.Report.Thresholds.Lower, 0)
+				assert.InDelta(t, 80.5, cli.Report.Thresholds.Upper, 0)
+				assert.Equal(t, map[string]string{"repo": "fcov", "n": "1"}, cli.Report.Label)
+				assert.Equal(t, 5, cli.Report.Max)
+				assert.False(t, cli.Report.Nest)
+			},
+		},
+		{
+			name:   "ok/list_separator",
+			config: "rule: ['a/**=80,90', 'b=1,2']\n",
+			toml:   "rule = ['a/**=80,90', 'b=1,2']\n",
+			args:   []string{"report", "cov.txt"},
+			check: func(t *testing.T, cli *configTestCLI) {
+				t.Helper()
+				assert.Equal(t, []string{"a/**=80,90", "b=1,2"}, cli.Report.Rule)
+			},
+		},
+		{
+			name:   "ok/command_section",
+			config: "filter: ['*']\nreport:\n  filter: ['pkg2/']\n",
+			toml:   "filter = ['*']\n[report]\nfilter = ['pkg2/']\n",
+			args:   []string{"other"},
+			check: func(t *testing.T, cli *configTestCLI) {
+				t.Helper()
+				assert.Equal(t, []string{"*"}, cli.Other.Filter)
+			},
+		},
+		{
+			name:   "ok/command_section_override",
+			config: "filter: ['*']\nreport:\n  filter: ['pkg2/']\n",
+			toml:   "filter = ['*']\n[report]\nfilter = ['pkg2/']\n",
+			args:   []string{"report", "cov.txt"},
+			check: func(t *testing.T, cli *configTestCLI) {
+				t.Helper()
+				assert.Equal(t, []string{"pkg2/"}, cli.Report.Filter)
+			},
+		},
+		{
+			name:   "ok/flag_precedence",
+			config: "filter: ['*']\nthresholds: 10,20\n",
+			toml:   "filter = ['*']\nthresholds = '10,20'\n",
+			args:   []string{"report", "--filter=pkg1/", "cov.txt"},
+			check: func(t *testing.T, cli *configTestCLI) {
+				t.Helper()
+				assert.Equal(t, []string{"pkg1/"}, cli.Report.Filter)
+				assert.InDelta(t, 10, cli.Report.Thresholds.Lower, 0)
+			},
+		},
+		{
+			name:   "err/unknown_flag",
+			config: "filters: ['*']\n",
+			toml:   "filters = ['*']\n",
+			args:   []string{"report", "cov.txt"},
+			expErr: "/.fcov.yaml: unknown flag or command 'filters'",
+		},
+		{
+			name:   "err/unknown_command_flag",
+			config: "other:\n  max: 5\n",
+			toml:   "[other]\nmax = 5\n",
+			args:   []string{"report", "cov.txt"},
+			expErr: "/.fcov.yaml: unknown flag 'max' of command 'other'",
+		},
+		{
+			name:   "err/invalid_value",
+			config: "max: five\n",
+			toml:   "max = 'five'\n",
+			args:   []string{"report", "cov.txt"},
+			expErr: `--max: expected a valid 64 bit int but got "five"`,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		for name, config := range map[string]string{".fcov.yaml": tt.config, ".fcov.toml": tt.toml} {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				fsys := memoryfs.New()
+				require.NoError(t, vfs.WriteFile(fsys, "/"+name, []byte(config), 0o644))
+				cli, parser, _ := newConfigTestParser(t, fsys)
+
+				_, err := parser.Parse(tt.args)
+				if tt.expErr != "" {
+					// The errors only differ by the name of the file.
+					assert.EqualError(t, err, strings.ReplaceAll(tt.expErr, ".fcov.yaml", name))
+					return
+				}
+				require.NoError(t, err)
+				tt.check(t, cli)
+			})
+		}
+	}
+}
+
+func TestConfigFind(t *testing.T) {
+	t.Parallel()
+
+	fsys := wdFS{FileSystem: memoryfs.New(), wd: "/repo/sub/dir"}
+	require.NoError(t, fsys.MkdirAll("/repo/sub/dir", 0o755))
+
+	_, _, cfg := newConfigTestParser(t, fsys)
+	assert.Empty(t, cfg.path)
+
+	require.NoError(t, vfs.WriteFile(fsys, "/repo/.fcov.yml", []byte("max: 1\n"), 0o644))
+	cli, parser, cfg := newConfigTestParser(t, fsys)
+	assert.Equal(t, "/repo/.fcov.yml", cfg.path)
+	_, err := parser.Parse([]string{"report", "cov.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cli.Report.Max)
+
+	// The closest file is used.
+	require.NoError(t, vfs.WriteFile(fsys, "/repo/sub/.fcov.yaml", []byte("max: 2\n"), 0o644))
+	_, _, cfg = newConfigTestParser(t, fsys)
+	assert.Equal(t, "/repo/sub/.fcov.yaml", cfg.path)
+
+	require.NoError(t, vfs.WriteFile(fsys, "/repo/sub/dir/.fcov.toml", []byte("max = 3\n"), 0o644))
+	cli, parser, cfg = newConfigTestParser(t, fsys)
+	assert.Equal(t, "/repo/sub/dir/.fcov.toml", cfg.path)
+	_, err = parser.Parse([]string{"report", "cov.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, cli.Report.Max)
+
+	// YAML files take precedence over TOML files in the same directory.
+	require.NoError(t, vfs.WriteFile(fsys, "/repo/sub/dir/.fcov.yml", []byte("max: 4\n"), 0o644))
+	_, _, cfg = newConfigTestParser(t, fsys)
+	assert.Equal(t, "/repo/sub/dir/.fcov.yml", cfg.path)
+}
+
+// wdFS is a filesystem with a fixed working directory.
+type wdFS struct {
+	vfs.FileSystem
+	wd string
+}
+
+func (fs wdFS) Getwd() (string, error) { return fs.wd, nil }
+
+//nolint:paralleltest // Environment variables can't be set in parallel tests.
+func TestConfigEnvPrecedence(t *testing.T) {
+	t.Setenv("FCOV_CONFIG_TEST_FILTER", "pkg2/")
+
+	for name, config := range map[string]string{
+		".fcov.yaml": "filter: ['*']\nmax: 3\n",
+		".fcov.toml": "filter = ['*']\nmax = 3\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := memoryfs.New()
+			require.NoError(t, vfs.WriteFile(fsys, "/"+name, []byte(config), 0o644))
+			cli, parser, _ := newConfigTestParser(t, fsys)
+
+			_, err := parser.Parse([]string{"report", "cov.txt"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"pkg2/"}, cli.Report.Filter)
+			assert.Equal(t, 3, cli.Report.Max)
+		})
+	}
+}
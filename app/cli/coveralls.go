@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/ci"
+	"go.hackfix.me/fcov/publish/coveralls"
+	"go.hackfix.me/fcov/report"
+)
+
+// Coveralls is the fcov coveralls command.
+type Coveralls struct {
+	Files         []string           `arg:"" optional:"" help:"One or more coverage files. Not required with --done."`
+	Filter        []string           `help:"Glob patterns applied on file paths to filter files from the coverage calculation. \n Example: '*,!*pkg*' would exclude all files except those that contain 'pkg'. " placeholder:"<glob pattern>"`
+	PathRemap     []report.PathRemap `help:"Replace a path prefix with another, to convert package paths into paths relative to the repository root. The first matching value is applied.\n Example: 'go.hackfix.me/fcov/='. " placeholder:"<from>=<to>"`
+	Root          string             `help:"Root directory of the repository, where the source files are read from. " default:"." placeholder:"<path>"`
+	URL           string             `name:"url" help:"URL of the Coveralls server. Defaults to the COVERALLS_ENDPOINT environment variable, or 'https://coveralls.io'. " placeholder:"<url>"`
+	Token         string             `help:"Repository token. Defaults to the COVERALLS_REPO_TOKEN environment variable. " placeholder:"<token>"`
+	ServiceName   string             `help:"Name of the CI service. Defaults to the COVERALLS_SERVICE_NAME environment variable, or is detected from the CI environment. " placeholder:"<name>"`
+	ServiceJobID  string             `name:"service-job-id" help:"ID of the CI job. Defaults to the COVERALLS_SERVICE_JOB_ID environment variable, or is detected from the CI environment. " placeholder:"<id>"`
+	ServiceNumber string             `help:"Number of the CI build, shared by all parallel jobs. Defaults to the COVERALLS_SERVICE_NUMBER environment variable, or is detected from the CI environment. " placeholder:"<number>"`
+	ServiceJobURL string             `name:"service-job-url" help:"Link to the CI job. Defaults to the COVERALLS_SERVICE_JOB_URL environment variable, or is detected from the CI environment. " placeholder:"<url>"`
+	Parallel      bool               `help:"Mark the job as one of several parallel jobs of the build, whose coverage is merged once --done is sent. "`
+	Done          bool               `help:"Notify the server that all parallel jobs of the build were sent, instead of sending a job. "`
+	FlagName      string             `help:"Name used to identify the job in the Coveralls UI, e.g. 'unit'. " placeholder:"<name>"`
+	Commit        string             `help:"Commit SHA the coverage files were created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<sha>"`
+	Branch        string             `help:"Branch the coverage files were created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<name>"`
+	PullRequest   int                `help:"Number of the pull request the coverage files were created for. Detected from the CI environment if not set. " placeholder:"<number>"`
+}
+
+// coverallsService holds the Coveralls name of a CI provider, and the
+// environment variables that contain the job ID and build number.
+type coverallsService struct {
+	Name, JobIDVar, NumberVar string
+}
+
+// coverallsServiceFor returns the Coveralls service of a CI provider, or a
+// zero value if the provider is not supported.
+func coverallsServiceFor(p ci.Provider) coverallsService {
+	switch p {
+	case ci.GitHubActions:
+		return coverallsService{"github", "GITHUB_RUN_ID", "GITHUB_RUN_NUMBER"}
+	case ci.GitLab:
+		return coverallsService{"gitlab-ci", "CI_JOB_ID", "CI_PIPELINE_IID"}
+	case ci.Buildkite:
+		return coverallsService{"buildkite", "BUILDKITE_JOB_ID", "BUILDKITE_BUILD_NUMBER"}
+	case ci.Jenkins:
+		return coverallsService{"jenkins", "BUILD_ID", "BUILD_NUMBER"}
+	case ci.CircleCI:
+		return coverallsService{"circleci", "CIRCLE_BUILD_NUM", "CIRCLE_WORKFLOW_ID"}
+	case ci.Drone:
+		return coverallsService{"drone", "DRONE_STEP_NUMBER", "DRONE_BUILD_NUMBER"}
+	default:
+		return coverallsService{}
+	}
+}
+
+// Run the fcov coveralls command.
+func (s *Coveralls) Run(appCtx *actx.Context) error {
+	ciInfo, _ := detectCI(appCtx)
+	service := coverallsServiceFor(ciInfo.Provider)
+	serviceNumber := envDefault(appCtx, s.ServiceNumber, "COVERALLS_SERVICE_NUMBER")
+	if serviceNumber == "" && service.NumberVar != "" {
+		serviceNumber = envDefault(appCtx, "", service.NumberVar)
+	}
+
+	client := coveralls.NewClient(envDefault(appCtx, s.URL, "COVERALLS_ENDPOINT"),
+		envDefault(appCtx, s.Token, "COVERALLS_REPO_TOKEN"), nil)
+
+	if s.Done {
+		if serviceNumber == "" {
+			return aerrors.NewRuntimeError("unknown build number", nil, "set it with --service-number")
+		}
+		if err := client.ParallelDone(appCtx.Ctx, serviceNumber); err != nil {
+			return fmt.Errorf("failed closing build %s: %w", serviceNumber, err)
+		}
+		appCtx.Logger.Info("closed parallel build", "number", serviceNumber)
+
+		return nil
+	}
+
+	if len(s.Files) == 0 {
+		return aerrors.NewRuntimeError("no coverage files", nil, "pass one or more coverage files, or --done")
+	}
+
+	cov, _, err := loadCoverage(appCtx, s.Files, s.Filter)
+	if err != nil {
+		return err
+	}
+	remaps := report.PathRemaps(s.PathRemap)
+	files, err := coveralls.SourceFiles(cov, appCtx.FS, s.Root, remaps.Apply)
+	if err != nil {
+		return fmt.Errorf("failed collecting source files: %w", err)
+	}
+	for _, f := range files {
+		if f.SourceDigest == "" {
+			appCtx.Logger.Warn("source file not found; set --path-remap or --root", "path", f.Name)
+		}
+	}
+
+	meta := newMetadata(appCtx, metadataFlags{Commit: s.Commit, Branch: s.Branch}, nil)
+	job := coveralls.Job{
+		ServiceName:   envDefault(appCtx, s.ServiceName, "COVERALLS_SERVICE_NAME"),
+		ServiceJobID:  envDefault(appCtx, s.ServiceJobID, "COVERALLS_SERVICE_JOB_ID"),
+		ServiceNumber: serviceNumber,
+		ServiceJobURL: envDefault(appCtx, s.ServiceJobURL, "COVERALLS_SERVICE_JOB_URL"),
+		Parallel:      s.Parallel,
+		FlagName:      s.FlagName,
+		CommitSHA:     meta.Commit,
+		SourceFiles:   files,
+	}
+	if job.ServiceName == "" {
+		job.ServiceName = service.Name
+	}
+	if job.ServiceJobID == "" && service.JobIDVar != "" {
+		job.ServiceJobID = envDefault(appCtx, "", service.JobIDVar)
+	}
+	if job.ServiceJobURL == "" {
+		job.ServiceJobURL = ciInfo.BuildURL
+	}
+	pullRequest := s.PullRequest
+	if pullRequest == 0 {
+		pullRequest = ciInfo.PullRequest
+	}
+	if pullRequest > 0 {
+		job.ServicePullRequest = strconv.Itoa(pullRequest)
+	}
+	if meta.Commit != "" {
+		job.Git = &coveralls.Git{Head: coveralls.GitHead{ID: meta.Commit}, Branch: meta.Branch}
+	}
+
+	resp, err := client.PostJob(appCtx.Ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed sending coverage to Coveralls: %w", err)
+	}
+	appCtx.Logger.Info("sent coverage", "url", resp.URL, "files", len(files))
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/diff"
+	"go.hackfix.me/fcov/publish/github"
+	"go.hackfix.me/fcov/report"
+)
+
+// GitHubFlags are the flags of commands that use the GitHub API.
+type GitHubFlags struct {
+	APIURL string `name:"api-url" help:"Base URL of the GitHub API. Defaults to the GITHUB_API_URL environment variable, or 'https://api.github.com'. " placeholder:"<url>"`
+	Token  string `help:"Token used to authenticate with the GitHub API. Defaults to the GITHUB_TOKEN environment variable. " placeholder:"<token>"`
+}
+
+// client returns a GitHub API client configured from the flags, or the
+// environment variables set by GitHub Actions.
+func (f GitHubFlags) client(appCtx *actx.Context) *github.Client {
+	return newGitHubClient(appCtx, f.APIURL, f.Token)
+}
+
+// newGitHubClient returns a GitHub API client that uses apiURL and token, or
+// the environment variables set by GitHub Actions if they're empty.
+func newGitHubClient(appCtx *actx.Context, apiURL, token string) *github.Client {
+	return github.NewClient(envDefault(appCtx, apiURL, "GITHUB_API_URL"),
+		envDefault(appCtx, token, "GITHUB_TOKEN"), nil)
+}
+
+// githubChanges returns a function that fetches the changes of pull requests
+// in repo from the GitHub API.
+func githubChanges(
+	appCtx *actx.Context, client *github.Client, repo string,
+) func(pr int) (diff.Changes, error) {
+	return func(pr int) (diff.Changes, error) {
+		if repo == "" {
+			return nil, aerrors.NewRuntimeError("unknown repository", nil, "set it with --repo")
+		}
+		changes, err := client.PullRequestChanges(appCtx.Ctx, repo, pr)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching the changes of pull request %d: %w", pr, err)
+		}
+		return changes, nil
+	}
+}
+
+// publishGitHubActions appends the Markdown report to the job summary, sets
+// the step outputs, and annotates the uncovered blocks in the files changed
+// by the pull request if annotate is not nil, if running in a GitHub Actions
+// workflow.
+func publishGitHubActions(
+	appCtx *actx.Context, sum *report.Report, opts report.RenderOptions,
+	renders map[report.Format]string, annotate *ChangesFlags,
+) error {
+	if !github.InActions(appCtx.Env) {
+		return nil
+	}
+	appCtx.Logger.Debug("publishing report to GitHub Actions")
+
+	gha := github.NewActions(appCtx.FS, appCtx.Env, appCtx.Stdout)
+
+	summary, ok := renders[report.Markdown]
+	if !ok {
+		var err error
+		if summary, err = sum.Render(report.Markdown, opts); err != nil {
+			return fmt.Errorf("failed rendering %s report: %w", report.Markdown, err)
+		}
+	}
+	if err := gha.AppendSummary(summary); err != nil {
+		return fmt.Errorf("failed writing job summary: %w", err)
+	}
+
+	pct := sum.Coverage * 100
+	err := gha.SetOutputs(
+		github.Output{Name: "total-coverage", Value: strconv.FormatFloat(pct, 'f', 2, 64)},
+		github.Output{Name: "total-statements", Value: strconv.Itoa(sum.NumStatements)},
+		github.Output{Name: "covered-statements", Value: strconv.Itoa(sum.HitCount)},
+		github.Output{Name: "health", Value: opts.Thresholds.Health(pct).String()},
+	)
+	if err != nil {
+		return fmt.Errorf("failed writing step outputs: %w", err)
+	}
+
+	if annotate == nil {
+		return nil
+	}
+
+	changes, err := annotate.changes(appCtx,
+		githubChanges(appCtx, newGitHubClient(appCtx, "", ""), sum.Metadata.Repository))
+	if err != nil {
+		return err
+	}
+	for _, b := range uncoveredChanges(appCtx, sum, opts, changes, false) {
+		err = gha.Annotate(github.Annotation{
+			Level: "warning", File: b.Path, Line: b.Start.Line, EndLine: b.End.Line,
+			Title: uncoveredTitle, Message: uncoveredMessage(b),
+		})
+		if err != nil {
+			return fmt.Errorf("failed annotating %s: %w", b.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// uncoveredTitle is the title of annotations of uncovered blocks.
+const uncoveredTitle = "Uncovered code"
+
+// uncoveredMessage returns the message of the annotation of an uncovered
+// block.
+func uncoveredMessage(b report.Uncovered) string {
+	if b.End.Line > b.Start.Line {
+		return fmt.Sprintf("Lines %d-%d are not covered by tests.", b.Start.Line, b.End.Line)
+	}
+
+	return fmt.Sprintf("Line %d is not covered by tests.", b.Start.Line)
+}
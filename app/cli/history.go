@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding"
+	"fmt"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/history"
+)
+
+// History is the fcov history command.
+type History struct {
+	File   string              `arg:"" help:"Path to the history file written by 'report --history-file'."`
+	Output HistoryOutputOption `short:"o" help:"Write the history to stdout or a file. More than one value can be provided, separated by comma.\nValues can either be formats ('txt', 'md' or 'svg'), filenames whose formats will be inferred by their extension, or '<format>:<filename>'. " default:"txt"`
+	Branch string              `help:"Only show the entries of this branch. " placeholder:"<name>"`
+	Last   int                 `help:"Only show the last N entries. 0 shows all entries. " placeholder:"<n>"`
+}
+
+// HistoryOutput is a destination the history should be written to. If
+// Filename is empty, the history will be written to stdout.
+type HistoryOutput struct {
+	Format   history.Format
+	Filename string
+}
+
+// HistoryOutputOption is a custom type that parses the history output option.
+type HistoryOutputOption []HistoryOutput
+
+var _ encoding.TextUnmarshaler = &HistoryOutputOption{}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for
+// HistoryOutputOption.
+func (o *HistoryOutputOption) UnmarshalText(text []byte) error {
+	return parseOutputs(string(text), history.FormatFromString, history.FormatFromString,
+		func(format history.Format, fname string) {
+			*o = append(*o, HistoryOutput{Format: format, Filename: fname})
+		})
+}
+
+// Run the fcov history command.
+func (s *History) Run(appCtx *actx.Context) error {
+	entries, err := history.Load(appCtx.FS, s.File)
+	if err != nil {
+		return fmt.Errorf("failed loading history: %w", err)
+	}
+
+	if s.Branch != "" {
+		filtered := entries[:0]
+		for _, e := range entries {
+			if e.Branch == s.Branch {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+	if s.Last > 0 && len(entries) > s.Last {
+		entries = entries[len(entries)-s.Last:]
+	}
+	if len(entries) == 0 {
+		appCtx.Logger.Warn("no history entries found", "file", s.File)
+	}
+
+	for _, out := range s.Output {
+		var render string
+		if render, err = history.Render(entries, out.Format); err != nil {
+			return fmt.Errorf("failed rendering history: %w", err)
+		}
+
+		if out.Filename == "" {
+			if _, err = fmt.Fprintln(appCtx.Stdout, render); err != nil {
+				return fmt.Errorf("failed writing history to stdout: %w", err)
+			}
+			continue
+		}
+
+		if err = vfs.WriteFile(appCtx.FS, out.Filename, []byte(render), 0o644); err != nil {
+			return fmt.Errorf("failed writing history output: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/diff"
+	"go.hackfix.me/fcov/publish/bitbucket"
+	"go.hackfix.me/fcov/report"
+)
+
+// Insights is the fcov insights command.
+type Insights struct {
+	ReportFlags  `embed:""`
+	ChangesFlags `embed:""`
+
+	APIURL string `name:"api-url" help:"Base URL of the Bitbucket Data Center server, e.g. 'https://bitbucket.example.com'. Defaults to the BITBUCKET_URL environment variable. " placeholder:"<url>"`
+	Token  string `help:"HTTP access token used to authenticate with the Bitbucket API. Defaults to the BITBUCKET_TOKEN environment variable. " placeholder:"<token>"`
+	Key    string `help:"Unique key of the Code Insights report. Publishing a report with the same key on the same commit replaces it. " default:"fcov" placeholder:"<key>"`
+	Title  string `help:"Title of the Code Insights report. " default:"Coverage" placeholder:"<title>"`
+}
+
+// Run the fcov insights command.
+func (s *Insights) Run(appCtx *actx.Context) error {
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
+
+	apiURL := envDefault(appCtx, s.APIURL, "BITBUCKET_URL")
+	if apiURL == "" {
+		return aerrors.NewRuntimeError("unknown Bitbucket server URL", nil, "set it with --api-url")
+	}
+
+	repo, commit := sum.Metadata.Repository, sum.Metadata.Commit
+	if repo == "" {
+		return aerrors.NewRuntimeError("unknown repository", nil,
+			"set it with --repo in the form of '<project key>/<repository slug>'")
+	}
+	if commit == "" {
+		return aerrors.NewRuntimeError("unknown commit", nil, "set it with --commit")
+	}
+
+	pct := sum.Coverage * 100
+	health := renderOpts.Thresholds.Health(pct)
+	result := bitbucket.ResultPass
+	if health == report.HealthCritical {
+		result = bitbucket.ResultFail
+	}
+	ciInfo, _ := detectCI(appCtx)
+
+	insightsReport := bitbucket.Report{
+		Title:    s.Title,
+		Details:  fmt.Sprintf("%.2f%% of statements are covered by tests.", pct),
+		Result:   result,
+		Reporter: "fcov",
+		Link:     ciInfo.BuildURL,
+		Data: []bitbucket.Data{
+			{Title: "Total coverage", Type: bitbucket.DataPercentage, Value: math.Round(pct*100) / 100},
+			{Title: "Covered statements", Type: bitbucket.DataNumber, Value: sum.HitCount},
+			{Title: "Total statements", Type: bitbucket.DataNumber, Value: sum.NumStatements},
+			{Title: "Health", Type: bitbucket.DataText, Value: health.String()},
+		},
+	}
+
+	client := bitbucket.NewClient(apiURL, envDefault(appCtx, s.Token, "BITBUCKET_TOKEN"), nil)
+	changes, err := s.changes(appCtx, func(pr int) (diff.Changes, error) {
+		changes, err := client.PullRequestChanges(appCtx.Ctx, repo, pr)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching the changes of pull request %d: %w", pr, err)
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return err
+	}
+	blocks := uncoveredChanges(appCtx, sum, renderOpts, changes, true)
+	annotations := make([]bitbucket.Annotation, 0, len(blocks))
+	for _, b := range blocks {
+		annotations = append(annotations, bitbucket.Annotation{
+			Path: b.Path, Line: b.Start.Line, Message: uncoveredMessage(b),
+			Severity: "LOW", Type: "CODE_SMELL",
+		})
+	}
+	if len(annotations) > bitbucket.MaxAnnotations {
+		appCtx.Logger.Warn("too many uncovered blocks, only some will be annotated",
+			"blocks", len(annotations), "max", bitbucket.MaxAnnotations)
+	}
+
+	err = client.PublishReport(appCtx.Ctx, repo, commit, s.Key, insightsReport, annotations)
+	if err != nil {
+		return fmt.Errorf("failed publishing Code Insights report: %w", err)
+	}
+	appCtx.Logger.Info("published Code Insights report", "key", s.Key, "commit", commit,
+		"annotations", min(len(annotations), bitbucket.MaxAnnotations))
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+
+	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/ci"
+	"go.hackfix.me/fcov/report"
+	"go.hackfix.me/fcov/vcs"
+)
+
+// metadataFlags are the report metadata values set via the CLI.
+type metadataFlags struct {
+	Repo, Commit, Branch string
+}
+
+// newMetadata returns the report metadata. Values not set via flags are
+// auto-detected from the CI environment and the Git repository in the working
+// directory, if possible.
+func newMetadata(appCtx *actx.Context, flags metadataFlags, inputs []report.Input) report.Metadata {
+	if ciInfo, ok := detectCI(appCtx); ok {
+		flags = flags.withDefaults(ciInfo)
+	}
+
+	meta := report.Metadata{
+		GoVersion:  runtime.Version(),
+		Timestamp:  timestamp(appCtx),
+		Repository: flags.Repo,
+		Commit:     flags.Commit,
+		Branch:     flags.Branch,
+		Inputs:     inputs,
+	}
+	if appCtx.Version != nil {
+		meta.Version = appCtx.Version.String()
+	}
+
+	if meta.Commit == "" || meta.Branch == "" {
+		if info, err := detectGit(appCtx); err == nil {
+			if meta.Commit == "" {
+				meta.Commit = info.Commit
+			}
+			// Only use the detected branch if it matches the commit.
+			if meta.Branch == "" && meta.Commit == info.Commit {
+				meta.Branch = info.Branch
+			}
+		}
+	}
+
+	return meta
+}
+
+// withDefaults returns a copy of the flags, with any empty values set to the
+// ones detected from the CI environment.
+func (f metadataFlags) withDefaults(info ci.Info) metadataFlags {
+	if f.Repo == "" {
+		f.Repo = info.Repository
+	}
+	if f.Commit == "" {
+		f.Commit = info.Commit
+	}
+	if f.Branch == "" {
+		f.Branch = info.Branch
+	}
+
+	return f
+}
+
+// detectCI returns the information about the build from the CI environment,
+// and false if no supported CI provider is detected.
+func detectCI(appCtx *actx.Context) (ci.Info, bool) {
+	if appCtx.Env == nil {
+		return ci.Info{}, false
+	}
+	info, ok := ci.Detect(appCtx.Env)
+	if ok {
+		appCtx.Logger.Debug("detected CI environment", "provider", info.Provider)
+	}
+
+	return info, ok
+}
+
+// timestamp returns the current time, or the time set in the
+// SOURCE_DATE_EPOCH environment variable, which allows creating reproducible
+// reports. See https://reproducible-builds.org/specs/source-date-epoch/
+func timestamp(appCtx *actx.Context) time.Time {
+	if appCtx.Env != nil {
+		if epoch := appCtx.Env.Get("SOURCE_DATE_EPOCH"); epoch != "" {
+			if sec, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+				return time.Unix(sec, 0).UTC()
+			}
+			appCtx.Logger.Warn("invalid SOURCE_DATE_EPOCH value", "value", epoch)
+		}
+	}
+
+	return time.Now().UTC()
+}
+
+func detectGit(appCtx *actx.Context) (vcs.Info, error) {
+	wd, err := appCtx.FS.Getwd()
+	if err != nil {
+		return vcs.Info{}, err //nolint:wrapcheck // Only logged.
+	}
+
+	info, err := vcs.DetectGit(appCtx.FS, wd)
+	if err != nil && !errors.Is(err, vcs.ErrNotFound) {
+		appCtx.Logger.Debug("failed detecting Git repository", "error", err)
+	}
+
+	return info, err //nolint:wrapcheck // Only logged.
+}
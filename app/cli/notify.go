@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/publish/webhook"
+	"go.hackfix.me/fcov/report"
+)
+
+// Notify is the fcov notify command.
+type Notify struct {
+	ReportFlags `embed:""`
+
+	URL    string            `name:"url" help:"URL the payload is sent to, e.g. a Slack incoming webhook. Not required with --dry-run. " placeholder:"<url>"`
+	Header map[string]string `help:"Extra HTTP header sent with the payload, e.g. for authentication. " mapsep:"," placeholder:"<name>=<value>"`
+	DryRun bool              `help:"Print the payload to stdout instead of sending it. "`
+}
+
+// Run the fcov notify command.
+func (s *Notify) Run(appCtx *actx.Context) error {
+	if s.Template == "" {
+		return aerrors.NewRuntimeError("no payload template", nil, "set it with --template")
+	}
+	if s.URL == "" && !s.DryRun {
+		return aerrors.NewRuntimeError("no webhook URL", nil, "set it with --url")
+	}
+
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := sum.Render(report.Template, renderOpts)
+	if err != nil {
+		return fmt.Errorf("failed rendering payload: %w", err)
+	}
+
+	if s.DryRun {
+		if !json.Valid([]byte(payload)) {
+			appCtx.Logger.Warn("payload is not valid JSON")
+		}
+		if _, err = fmt.Fprintln(appCtx.Stdout, payload); err != nil {
+			return fmt.Errorf("failed writing payload: %w", err)
+		}
+		return nil
+	}
+
+	err = webhook.NewClient(nil).Send(appCtx.Ctx, s.URL, s.Header, []byte(payload))
+	if err != nil {
+		return fmt.Errorf("failed sending notification: %w", err)
+	}
+	appCtx.Logger.Info("sent notification")
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/publish/otlp"
+	"go.hackfix.me/fcov/report"
+)
+
+// OTLP is the fcov otlp command.
+type OTLP struct {
+	ReportFlags `embed:""`
+
+	Endpoint          string            `help:"Base URL of the OTLP/HTTP collector. Metrics are sent to the '/v1/metrics' path. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable, or 'http://localhost:4318'. " placeholder:"<url>"`
+	Header            map[string]string `help:"HTTP header sent to the collector, e.g. for authentication. Defaults to the OTEL_EXPORTER_OTLP_HEADERS environment variable. " mapsep:"," placeholder:"<name>=<value>"`
+	ResourceAttribute map[string]string `help:"Extra resource attribute of the metrics. The repository, commit and branch are always added as the 'vcs.repository.name', 'vcs.ref.head.revision' and 'vcs.ref.head.name' attributes. " mapsep:"," placeholder:"<name>=<value>"`
+	FileMetrics       bool              `help:"Send data points for each file, in addition to the total and each package. "`
+}
+
+// Run the fcov otlp command.
+func (s *OTLP) Run(appCtx *actx.Context) error {
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
+
+	resource := map[string]string{
+		"service.name":          "fcov",
+		"vcs.repository.name":   sum.Metadata.Repository,
+		"vcs.ref.head.revision": sum.Metadata.Commit,
+		"vcs.ref.head.name":     sum.Metadata.Branch,
+	}
+	for name, value := range s.ResourceAttribute {
+		resource[name] = value
+	}
+
+	gauges := []otlp.Gauge{
+		{Name: "fcov.coverage.ratio", Description: "Ratio of statements covered by tests.", Unit: "1"},
+		{Name: "fcov.statements", Description: "Number of statements.", Unit: "{statement}", Int: true},
+		{
+			Name: "fcov.statements.covered", Description: "Number of statements covered by tests.",
+			Unit: "{statement}", Int: true,
+		},
+	}
+	for _, row := range sum.StatsRows(renderOpts) {
+		if row.Scope == report.ScopeFile && !s.FileMetrics {
+			continue
+		}
+		attrs := map[string]string{"scope": row.Scope, "package": row.Package, "file": row.File}
+		gauges[0].DataPoints = append(gauges[0].DataPoints, otlp.DataPoint{Attributes: attrs, Value: row.Coverage})
+		gauges[1].DataPoints = append(gauges[1].DataPoints,
+			otlp.DataPoint{Attributes: attrs, Value: float64(row.NumStatements)})
+		gauges[2].DataPoints = append(gauges[2].DataPoints,
+			otlp.DataPoint{Attributes: attrs, Value: float64(row.HitCount)})
+	}
+
+	metrics := otlp.Metrics{
+		Resource:  resource,
+		ScopeName: "fcov",
+		Time:      sum.Metadata.Timestamp,
+		Gauges:    gauges,
+	}
+	if appCtx.Version != nil {
+		metrics.ScopeVersion = appCtx.Version.Semantic
+	}
+
+	headers := s.Header
+	if len(headers) == 0 {
+		headers = parseOTLPHeaders(envDefault(appCtx, "", "OTEL_EXPORTER_OTLP_HEADERS"))
+	}
+	client := otlp.NewClient(envDefault(appCtx, s.Endpoint, "OTEL_EXPORTER_OTLP_ENDPOINT"), headers, nil)
+	if err = client.Export(appCtx.Ctx, metrics); err != nil {
+		return fmt.Errorf("failed sending coverage to the OTLP collector: %w", err)
+	}
+	appCtx.Logger.Info("exported coverage metrics", "data_points", len(gauges[0].DataPoints))
+
+	return nil
+}
+
+// parseOTLPHeaders parses the value of the OTEL_EXPORTER_OTLP_HEADERS
+// environment variable, a comma-separated list of '<name>=<value>' pairs with
+// percent-encoded values. Invalid pairs are skipped.
+func parseOTLPHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		name, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		val, err := url.PathUnescape(strings.TrimSpace(val))
+		if err != nil {
+			continue
+		}
+		headers[strings.TrimSpace(name)] = val
+	}
+
+	return headers
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		in   string
+		exp  map[string]string
+	}{
+		{name: "empty", in: "", exp: map[string]string{}},
+		{
+			name: "multiple",
+			in:   "api-key=secret, x-tenant = org ",
+			exp:  map[string]string{"api-key": "secret", "x-tenant": "org"},
+		},
+		{
+			name: "percent_encoded",
+			in:   "Authorization=Bearer%20token,x-query=a%3Db%2Cc",
+			exp:  map[string]string{"Authorization": "Bearer token", "x-query": "a=b,c"},
+		},
+		{
+			name: "invalid",
+			in:   "novalue,=noname,bad=%zz,ok=1",
+			exp:  map[string]string{"ok": "1"},
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.exp, parseOTLPHeaders(tt.in))
+		})
+	}
+}
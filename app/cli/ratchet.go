@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/ratchet"
+	"go.hackfix.me/fcov/report"
+)
+
+// Ratchet is the fcov ratchet command.
+type Ratchet struct {
+	Files             []string `arg:"" help:"One or more coverage files."`
+	Filter            []string `help:"Glob patterns applied on file paths to filter files from the coverage calculation. \n Example: '*,!*pkg*' would exclude all files except those that contain 'pkg'. " placeholder:"<glob pattern>"`
+	TrimPackagePrefix string   `help:"Trim this prefix string from the package paths recorded in the ratchet file. "`
+	File              string   `help:"Path to the ratchet file with the minimum coverage of each package. " default:".fcov-ratchet" placeholder:"<path>"`
+	Update            bool     `help:"Raise the minimums of packages whose coverage increased, and add packages that don't have one. Minimums are never lowered. "`
+	Strict            bool     `help:"Fail the check for packages that don't have a minimum, e.g. new packages, instead of only warning about them. "`
+	Prune             bool     `help:"With --update, remove the minimums of packages that are not part of the coverage files. "`
+}
+
+// Run the fcov ratchet command.
+func (s *Ratchet) Run(appCtx *actx.Context) error {
+	minimums, err := ratchet.Load(appCtx.FS, s.File)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && s.Update:
+		// The file is created with the current coverage.
+		minimums = ratchet.Minimums{}
+	case errors.Is(err, fs.ErrNotExist):
+		return aerrors.NewRuntimeError(err.Error(), nil, "create it with --update")
+	case err != nil:
+		return fmt.Errorf("failed loading ratchet file: %w", err)
+	}
+
+	cov, _, err := loadCoverage(appCtx, s.Files, s.Filter)
+	if err != nil {
+		return err
+	}
+	coverage := make(map[string]float64)
+	for _, row := range report.Create(cov).StatsRows(report.RenderOptions{TrimPackagePrefix: s.TrimPackagePrefix}) {
+		if row.Scope == report.ScopePackage {
+			coverage[row.Package] = row.Coverage * 100
+		}
+	}
+
+	res := minimums.Check(coverage)
+	for _, v := range res.Violations {
+		_, err = fmt.Fprintf(appCtx.Stdout, "%s: %.2f%% is below the minimum of %.2f%% (%+.2f%%)\n",
+			v.Package, v.Coverage, v.Minimum, v.Coverage-v.Minimum)
+		if err != nil {
+			return fmt.Errorf("failed writing ratchet violations: %w", err)
+		}
+	}
+
+	switch {
+	case s.Update:
+		if err = ratchet.Save(appCtx.FS, s.File, minimums.Update(coverage, s.Prune)); err != nil {
+			return fmt.Errorf("failed updating ratchet file: %w", err)
+		}
+		appCtx.Logger.Info("updated ratchet file", "file", s.File,
+			"raised", len(res.Raised), "added", len(res.Added))
+	case s.Strict:
+		for _, pkg := range res.Added {
+			_, err = fmt.Fprintf(appCtx.Stdout, "%s: %.2f%% has no minimum coverage\n",
+				pkg, ratchet.Truncate(coverage[pkg]))
+			if err != nil {
+				return fmt.Errorf("failed writing ratchet violations: %w", err)
+			}
+		}
+	case len(res.Added) > 0:
+		appCtx.Logger.Warn("packages without a minimum coverage", "packages", strings.Join(res.Added, ","),
+			"hint", "add them with --update")
+	}
+
+	switch {
+	case len(res.Violations) > 0:
+		return aerrors.NewRuntimeError(
+			fmt.Sprintf("coverage of %d package(s) dropped below the minimum", len(res.Violations)),
+			nil, "add tests, or lower the minimums in "+s.File+" by hand")
+	case len(res.Added) > 0 && s.Strict && !s.Update:
+		return aerrors.NewRuntimeError(
+			fmt.Sprintf("%d package(s) don't have a minimum coverage", len(res.Added)),
+			nil, "add them to "+s.File+" with --update")
+	}
+
+	return nil
+}
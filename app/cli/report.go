@@ -2,16 +2,20 @@ package cli
 
 import (
 	"bufio"
+	"crypto/sha256"
 	"encoding"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	gitignore "github.com/sabhiram/go-gitignore"
 
 	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/history"
 	"go.hackfix.me/fcov/parse"
 	"go.hackfix.me/fcov/report"
 	"go.hackfix.me/fcov/types"
@@ -19,14 +23,49 @@ import (
 
 // Report is the fcov report command.
 type Report struct {
-	Files             []string         `arg:"" help:"One or more coverage files."` // not using 'existingfile' modifier since it makes it difficult to test with an in-memory FS
-	Filter            []string         `help:"Glob patterns applied on file paths to filter files from the coverage calculation and output. \n Example: '*,!*pkg*' would exclude all files except those that contain 'pkg'. " placeholder:"<glob pattern>"`
-	FilterOutput      []string         `help:"Glob patterns applied on file paths to filter files from the output, but *not* from the coverage calculation. " placeholder:"<glob pattern>"`
-	FilterOutputFile  string           `help:"Path to a file that contains newline-separated file paths to include in the output.\nIf specified, it overrides --filter-output. " placeholder:"<path>"`
-	NestFiles         bool             `help:"Nest files under packages when rendering to text or Markdown. " default:"true" negatable:""`
-	Output            OutputOption     `short:"o" help:"Write the report to stdout or a file. More than one value can be provided, separated by comma.\nValues can either be formats ('txt' or 'md'), or filenames whose formats will be inferred by their extension.\n Example: 'txt,report.md' would write the report in text format to stdout, and to a report.md file in Markdown format. " default:"txt"`
-	Thresholds        ThresholdsOption `help:"Lower and upper threshold percentages for badge and health indicators. " default:"50,75"`
-	TrimPackagePrefix string           `help:"Trim this prefix string from the package path in the output. "`
+	ReportFlags  `embed:""`
+	ChangesFlags `embed:""`
+
+	GithubActions     bool          `help:"When running in GitHub Actions, append the Markdown report to the job summary, and set the 'total-coverage', 'total-statements', 'covered-statements' and 'health' step outputs. " default:"true" negatable:""`
+	GithubAnnotations bool          `help:"When running in GitHub Actions, emit warning annotations for uncovered code blocks in the files changed by the pull request, read from --diff or fetched from the GitHub API, that are not excluded from the output. "`
+	HistoryFile       string        `help:"Append the total and package coverage of this run to a history file, whose trends can be shown with the 'history' command. " placeholder:"<path>"`
+	HistoryMaxEntries int           `help:"Maximum number of entries kept in the history file. The oldest entries are removed first. 0 disables the limit. " placeholder:"<n>"`
+	HistoryMaxAge     time.Duration `help:"Maximum age of entries kept in the history file, relative to the newest entry, e.g. '2160h' for 90 days. 0 disables the limit. " placeholder:"<duration>"`
+	Output            OutputOption  `short:"o" help:"Write the report to stdout or a file. More than one value can be provided, separated by comma.\nValues can either be formats ('txt', 'md', 'tmpl', 'json', 'badge', 'shields', 'cobertura', 'sarif', 'junit', 'sonarqube' or 'openmetrics'), filenames whose formats will be inferred by their extension, or '<format>:<filename>'.\n Example: 'txt,report.md' would write the report in text format to stdout, and to a report.md file in Markdown format. " default:"txt"`
+}
+
+// ReportFlags are the flags of commands that create a coverage report.
+type ReportFlags struct {
+	Files             []string               `arg:"" help:"One or more coverage files."` // not using 'existingfile' modifier since it makes it difficult to test with an in-memory FS
+	Filter            []string               `help:"Glob patterns applied on file paths to filter files from the coverage calculation and output. \n Example: '*,!*pkg*' would exclude all files except those that contain 'pkg'. " placeholder:"<glob pattern>"`
+	FilterOutput      []string               `help:"Glob patterns applied on file paths to filter files from the output, but *not* from the coverage calculation. " placeholder:"<glob pattern>"`
+	FilterOutputFile  string                 `help:"Path to a file that contains newline-separated file paths to include in the output.\nIf specified, it overrides --filter-output. " placeholder:"<path>"`
+	LinkTemplate      string                 `help:"URL template used to link package and file names in the Markdown output to their source. Supported placeholders: {repo}, {sha}, {path} and {line}.\nIf set to 'auto', the template is detected from the CI environment.\n Example: 'https://github.com/{repo}/blob/{sha}/{path}#L{line}'. " placeholder:"<url>"`
+	Repo              string                 `help:"Repository name used in links, e.g. 'hackfixme/fcov'. Detected from the CI environment if not set. " placeholder:"<name>"`
+	Commit            string                 `help:"Commit SHA the report is created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<sha>"`
+	Branch            string                 `help:"Branch the report is created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<name>"`
+	Metadata          bool                   `help:"Append the report metadata (commit, branch, creation time and fcov version) to the text and Markdown output. "`
+	PathRemap         []report.PathRemap     `help:"Replace a path prefix with another, to convert package paths into paths relative to the repository root. The first matching value is applied.\n Example: 'go.hackfix.me/fcov/='. " placeholder:"<from>=<to>"`
+	MetricLabel       map[string]string      `help:"Extra label added to every sample of the 'openmetrics' output format. The placeholders {repo}, {sha} and {branch} in values are replaced with the report metadata. The names 'scope', 'package' and 'file' are reserved.\n Example: 'repo={repo},branch={branch}'. " mapsep:"," placeholder:"<name>=<value>"`
+	MarkdownMaxSize   int                    `help:"Maximum number of characters of the Markdown output. If exceeded, file details and packages are progressively omitted. 0 disables the limit. " placeholder:"<chars>"`
+	NestFiles         bool                   `help:"Nest files under packages when rendering to text or Markdown. " default:"true" negatable:""`
+	Template          string                 `help:"Path to a Go text/template file used to render the 'tmpl' output format. " placeholder:"<path>"`
+	Baseline          string                 `help:"Path to a JSON report of a previous run, e.g. of the default branch, used to compute the coverage delta in templates. " placeholder:"<path>"`
+	BadgeLabel        string                 `help:"Label text of the 'badge' and 'shields' output formats. " default:"coverage"`
+	Thresholds        ThresholdsOption       `help:"Lower and upper threshold percentages for badge and health indicators. " default:"50,75"`
+	HealthIndicators  bool                   `help:"Add a health indicator to each package and file row of the text and Markdown output. Markdown rows get an icon, and text rows written to a terminal are colored, unless the NO_COLOR environment variable is set. "`
+	ThresholdRule     []report.ThresholdRule `help:"Lower and upper threshold percentages for the packages and files whose path matches a glob pattern in gitignore format, overriding --thresholds. More than one value can be provided, separated by semicolon. The first matching rule is applied.\n Example: 'internal/core/**=80,90;cmd/**=30,50'. " sep:";" placeholder:"<glob pattern>=<lower>,<upper>"`
+	TrimPackagePrefix string                 `help:"Trim this prefix string from the package path in the output. "`
+}
+
+// Validate is called by kong after the flag values are resolved from the
+// command line, environment variables and configuration file.
+func (f *ReportFlags) Validate() error {
+	if err := report.ValidateMetricLabels(f.MetricLabel); err != nil {
+		return fmt.Errorf("invalid --metric-label value: %w", err)
+	}
+
+	return nil
 }
 
 // Output is a destination the report should be written to. If Filename is
@@ -44,70 +83,65 @@ var _ encoding.TextUnmarshaler = &OutputOption{}
 // UnmarshalText implements the encoding.TextUnmarshaler interface for
 // OutputOption.
 func (o *OutputOption) UnmarshalText(text []byte) error {
-	options := strings.Split(string(text), ",")
+	return parseOutputs(string(text), report.FormatFromString, report.FormatFromExtension,
+		func(format report.Format, fname string) {
+			*o = append(*o, Output{Format: format, Filename: fname})
+		})
+}
 
-	for _, option := range options {
-		out := Output{}
-		format := report.FormatFromString(option)
+// parseOutputs parses a comma-separated list of outputs, which can either be
+// formats, filenames whose formats will be inferred by their extension, or
+// '<format>:<filename>'. fromName and fromExt return the format of a name or
+// extension, or an empty format if it's unknown. add is called for each output,
+// with an empty filename for outputs written to stdout.
+func parseOutputs[F ~string](
+	text string, fromName, fromExt func(string) F, add func(format F, fname string),
+) error {
+	for _, option := range strings.Split(text, ",") {
+		fname := ""
+		format := fromName(option)
+		if fmtName, fn, ok := strings.Cut(option, ":"); format == "" && ok {
+			// An explicit format for a filename, e.g. 'tmpl:comment.md'.
+			if format = fromName(fmtName); format != "" {
+				fname = fn
+			}
+		}
 		if format == "" {
 			// Assume it's a filename, and infer the format from the extension.
 			ext := filepath.Ext(option)
 			if ext == "" {
 				return fmt.Errorf("invalid output value: %s", option)
 			}
-			format = report.FormatFromString(ext[1:]) // Remove the leading dot
-			if format == "" {
+			if format = fromExt(ext); format == "" {
 				return fmt.Errorf("invalid output format: %s", ext[1:])
 			}
-			out.Filename = option
+			fname = option
 		}
 
-		out.Format = format
-
-		*o = append(*o, out)
+		add(format, fname)
 	}
 
 	return nil
 }
 
-// ThresholdsOption is a custom type that parses the thresholds option.
+// ThresholdsOption is a custom type that parses the thresholds option, with
+// the UnmarshalText method of report.Thresholds.
 type ThresholdsOption struct {
-	Lower, Upper float64
+	report.Thresholds
 }
 
 var _ encoding.TextUnmarshaler = &ThresholdsOption{}
 
-// UnmarshalText implements the encoding.TextUnmarshaler interface for
-// ThresholdsOption.
-func (o *ThresholdsOption) UnmarshalText(text []byte) error {
-	parts := strings.Split(string(text), ",")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid thresholds value: %s", text)
-	}
-
-	var err error
-	if o.Lower, err = strconv.ParseFloat(parts[0], 64); err != nil {
-		return fmt.Errorf("invalid lower threshold '%s': %w", parts[0], err)
-	}
-	if o.Upper, err = strconv.ParseFloat(parts[1], 64); err != nil {
-		return fmt.Errorf("invalid upper threshold '%s': %w", parts[1], err)
-	}
-
-	return nil
-}
-
-// Run the fcov report command.
+// createReport loads the coverage files, and returns the report and the
+// options to render it with.
 // TODO: This currently assumes Go coverage processing. Either correctly infer so,
 // or add a CLI flag to use Go mode reporting.
-func (s *Report) Run(appCtx *actx.Context) error {
-	cov := types.NewCoverage()
-	filterCov := gitignore.CompileIgnoreLines(s.Filter...)
-
+func (s *ReportFlags) createReport(appCtx *actx.Context) (*report.Report, report.RenderOptions, error) {
 	filterOutLines := s.FilterOutput
 	if s.FilterOutputFile != "" {
 		file, err := appCtx.FS.Open(s.FilterOutputFile)
 		if err != nil {
-			return fmt.Errorf("failed opening filter output file: %w", err)
+			return nil, report.RenderOptions{}, fmt.Errorf("failed opening filter output file: %w", err)
 		}
 		defer file.Close()
 
@@ -118,24 +152,117 @@ func (s *Report) Run(appCtx *actx.Context) error {
 
 		filterOutLines, err = createOutputFilterFromFile(file)
 		if err != nil {
-			return fmt.Errorf("failed reading filter output file: %w", err)
+			return nil, report.RenderOptions{}, fmt.Errorf("failed reading filter output file: %w", err)
 		}
 	}
 	filterOut := gitignore.CompileIgnoreLines(filterOutLines...)
 
-	for _, fpath := range s.Files {
+	cov, inputs, err := loadCoverage(appCtx, s.Files, s.Filter)
+	if err != nil {
+		return nil, report.RenderOptions{}, err
+	}
+
+	sum := report.Create(cov)
+	sum.Metadata = newMetadata(appCtx, metadataFlags{
+		Repo: s.Repo, Commit: s.Commit, Branch: s.Branch,
+	}, inputs)
+
+	renderOpts := report.RenderOptions{
+		NestFiles:         s.NestFiles,
+		Filter:            filterOut,
+		Thresholds:        s.Thresholds.Thresholds,
+		ThresholdRules:    s.ThresholdRule,
+		HealthIndicators:  s.HealthIndicators,
+		TrimPackagePrefix: s.TrimPackagePrefix,
+		BadgeLabel:        s.BadgeLabel,
+		MaxSize:           s.MarkdownMaxSize,
+		LinkTemplate:      s.LinkTemplate,
+		PathRemaps:        s.PathRemap,
+		ShowMetadata:      s.Metadata,
+		MetricLabels:      metricLabels(s.MetricLabel, sum.Metadata),
+	}
+
+	if s.LinkTemplate == "auto" {
+		ciInfo, _ := detectCI(appCtx)
+		renderOpts.LinkTemplate = ciInfo.LinkTemplate()
+		if renderOpts.LinkTemplate == "" {
+			appCtx.Logger.Warn("failed detecting the link template from the CI environment")
+		}
+	}
+
+	if s.Baseline != "" {
+		data, err := vfs.ReadFile(appCtx.FS, s.Baseline)
+		if err != nil {
+			return nil, report.RenderOptions{}, fmt.Errorf("failed reading baseline file: %w", err)
+		}
+		baseline, err := report.ParseJSONTotal(data)
+		if err != nil {
+			return nil, report.RenderOptions{}, fmt.Errorf("failed reading baseline file: %w", err)
+		}
+		renderOpts.Baseline = &baseline
+	}
+
+	if s.Template != "" {
+		tmpl, err := vfs.ReadFile(appCtx.FS, s.Template)
+		if err != nil {
+			return nil, report.RenderOptions{}, fmt.Errorf("failed reading template file: %w", err)
+		}
+		renderOpts.Template = string(tmpl)
+	}
+
+	return sum, renderOpts, nil
+}
+
+// metricLabels returns the labels with the metadata placeholders in their
+// values replaced.
+func metricLabels(labels map[string]string, meta report.Metadata) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	r := strings.NewReplacer(
+		"{repo}", meta.Repository, "{sha}", meta.Commit, "{branch}", meta.Branch,
+	)
+	out := make(map[string]string, len(labels))
+	for name, value := range labels {
+		out[name] = r.Replace(value)
+	}
+
+	return out
+}
+
+// loadCoverage parses the coverage files, excluding the paths that match the
+// filter patterns. It also returns the SHA-256 digest of each file.
+func loadCoverage(appCtx *actx.Context, files, filter []string) (*types.Coverage, []report.Input, error) {
+	cov := types.NewCoverage()
+	filterCov := gitignore.CompileIgnoreLines(filter...)
+
+	inputs := make([]report.Input, 0, len(files))
+	for _, fpath := range files {
 		file, err := appCtx.FS.Open(fpath)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		defer file.Close()
 
-		if err = parse.Go(file, cov, filterCov); err != nil {
-			return err
+		digest := sha256.New()
+		if err = parse.Go(io.TeeReader(file, digest), cov, filterCov); err != nil {
+			return nil, nil, err
 		}
+		inputs = append(inputs, report.Input{
+			Path: fpath, SHA256: hex.EncodeToString(digest.Sum(nil)),
+		})
 	}
 
-	sum := report.Create(cov)
+	return cov, inputs, nil
+}
+
+// Run the fcov report command.
+func (s *Report) Run(appCtx *actx.Context) error {
+	sum, renderOpts, err := s.createReport(appCtx)
+	if err != nil {
+		return err
+	}
 
 	renders := make(map[report.Format]string)
 	for _, out := range s.Output {
@@ -144,13 +271,21 @@ func (s *Report) Run(appCtx *actx.Context) error {
 			ok     bool
 		)
 		if render, ok = renders[out.Format]; !ok {
-			render = sum.Render(
-				out.Format, s.NestFiles, filterOut, s.Thresholds.Lower,
-				s.Thresholds.Upper, s.TrimPackagePrefix)
+			render, err = sum.Render(out.Format, renderOpts)
+			if err != nil {
+				return fmt.Errorf("failed rendering %s report: %w", out.Format, err)
+			}
 			renders[out.Format] = render
 		}
 
 		if out.Filename == "" {
+			if out.Format == report.Text && useColor(appCtx) {
+				colorOpts := renderOpts
+				colorOpts.Color = true
+				if render, err = sum.Render(out.Format, colorOpts); err != nil {
+					return fmt.Errorf("failed rendering %s report: %w", out.Format, err)
+				}
+			}
 			if _, err := fmt.Fprintln(appCtx.Stdout, render); err != nil {
 				return err
 			}
@@ -162,9 +297,34 @@ func (s *Report) Run(appCtx *actx.Context) error {
 		}
 	}
 
+	if s.HistoryFile != "" {
+		retention := history.Retention{MaxEntries: s.HistoryMaxEntries, MaxAge: s.HistoryMaxAge}
+		err = history.Append(appCtx.FS, s.HistoryFile, history.NewEntry(sum, s.TrimPackagePrefix), retention)
+		if err != nil {
+			return fmt.Errorf("failed recording history: %w", err)
+		}
+	}
+
+	if s.GithubActions {
+		var annotate *ChangesFlags
+		if s.GithubAnnotations {
+			annotate = &s.ChangesFlags
+		}
+		if err := publishGitHubActions(appCtx, sum, renderOpts, renders, annotate); err != nil {
+			return fmt.Errorf("failed publishing report to GitHub Actions: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// useColor returns true if colored output should be written to stdout, which
+// is when it's a terminal, and the NO_COLOR environment variable is not set.
+// See https://no-color.org/.
+func useColor(appCtx *actx.Context) bool {
+	return appCtx.IsStdoutTTY && envDefault(appCtx, "", "NO_COLOR") == ""
+}
+
 func createOutputFilterFromFile(file vfs.File) ([]string, error) {
 	scanner := bufio.NewScanner(file)
 	filter := []string{"*"} // exclude everything
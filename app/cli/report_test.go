@@ -8,6 +8,8 @@ import (
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/report"
 )
 
 // TODO: Add more test cases.
@@ -43,3 +45,50 @@ func TestCreateOutputFilterFromFile(t *testing.T) {
 
 	assert.Equal(t, []string{"*", "!pkg1/file1.go", "!pkg1/file2.go", "!pkg2/"}, filterOut)
 }
+
+func TestOutputOptionUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		want   OutputOption
+		expErr string
+	}{
+		{
+			input: "txt,md",
+			want:  OutputOption{{Format: report.Text}, {Format: report.Markdown}},
+		},
+		{
+			input: "report.md,out/report.txt",
+			want: OutputOption{
+				{Format: report.Markdown, Filename: "report.md"},
+				{Format: report.Text, Filename: "out/report.txt"},
+			},
+		},
+		{
+			input: "tmpl,tmpl:comment.md",
+			want: OutputOption{
+				{Format: report.Template},
+				{Format: report.Template, Filename: "comment.md"},
+			},
+		},
+		{input: "report", expErr: "invalid output value: report"},
+		{input: "report.xyz", expErr: "invalid output format: xyz"},
+		{input: "xyz:report.xyz", expErr: "invalid output format: xyz"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			var got OutputOption
+			err := got.UnmarshalText([]byte(tt.input))
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	actx "go.hackfix.me/fcov/app/context"
+	aerrors "go.hackfix.me/fcov/app/errors"
+	"go.hackfix.me/fcov/ci"
+	"go.hackfix.me/fcov/publish/codecov"
+)
+
+// Upload is the fcov upload command.
+type Upload struct {
+	Files       []string `arg:"" help:"One or more coverage files."`
+	URL         string   `name:"url" help:"URL of the Codecov-compatible server. Defaults to the CODECOV_URL environment variable, or 'https://codecov.io'. " placeholder:"<url>"`
+	Token       string   `help:"Repository upload token. Defaults to the CODECOV_TOKEN environment variable. " placeholder:"<token>"`
+	Flags       []string `help:"Flags used to group uploads, e.g. 'unit,linux'. " placeholder:"<flag>"`
+	Name        string   `help:"Name of the upload. " placeholder:"<name>"`
+	Repo        string   `help:"Repository name, e.g. 'hackfixme/fcov'. Detected from the CI environment if not set. " placeholder:"<name>"`
+	Commit      string   `help:"Commit SHA the coverage files were created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<sha>"`
+	Branch      string   `help:"Branch the coverage files were created for. Detected from the CI environment or the Git repository in the working directory if not set. " placeholder:"<name>"`
+	PullRequest int      `help:"Number of the pull request the coverage files were created for. Detected from the CI environment if not set. " placeholder:"<number>"`
+	Root        string   `help:"Root directory of the repository, whose files are sent to the server to fix the paths in the coverage files. " default:"." placeholder:"<path>"`
+}
+
+// codecovService returns the Codecov name of a CI provider, or an empty
+// string if the provider is not supported.
+func codecovService(p ci.Provider) string {
+	switch p {
+	case ci.GitHubActions:
+		return "github-actions"
+	case ci.GitLab:
+		return "gitlab"
+	case ci.Buildkite:
+		return "buildkite"
+	case ci.Jenkins:
+		return "jenkins"
+	case ci.CircleCI:
+		return "circleci"
+	case ci.Drone:
+		return "drone.io"
+	default:
+		return ""
+	}
+}
+
+// Run the fcov upload command.
+func (s *Upload) Run(appCtx *actx.Context) error {
+	files := make([]codecov.File, 0, len(s.Files))
+	for _, fpath := range s.Files {
+		data, err := vfs.ReadFile(appCtx.FS, fpath)
+		if err != nil {
+			return fmt.Errorf("failed reading coverage file: %w", err)
+		}
+		files = append(files, codecov.File{Path: fpath, Data: data})
+	}
+
+	meta := newMetadata(appCtx, metadataFlags{
+		Repo: s.Repo, Commit: s.Commit, Branch: s.Branch,
+	}, nil)
+	if meta.Commit == "" {
+		return aerrors.NewRuntimeError("unknown commit", nil, "set it with --commit")
+	}
+
+	ciInfo, _ := detectCI(appCtx)
+	opts := codecov.UploadOptions{
+		Slug:        meta.Repository,
+		Commit:      meta.Commit,
+		Branch:      meta.Branch,
+		PullRequest: s.PullRequest,
+		Flags:       s.Flags,
+		Name:        s.Name,
+		Service:     codecovService(ciInfo.Provider),
+		BuildURL:    ciInfo.BuildURL,
+	}
+	if appCtx.Version != nil {
+		opts.Version = appCtx.Version.Semantic
+	}
+	if opts.PullRequest == 0 {
+		opts.PullRequest = ciInfo.PullRequest
+	}
+
+	network, err := codecov.Network(appCtx.FS, s.Root)
+	if err != nil {
+		return fmt.Errorf("failed collecting the repository file list: %w", err)
+	}
+
+	client := codecov.NewClient(envDefault(appCtx, s.URL, "CODECOV_URL"),
+		envDefault(appCtx, s.Token, "CODECOV_TOKEN"), nil)
+	resultURL, err := client.Upload(appCtx.Ctx, opts, codecov.Payload(network, files))
+	if err != nil {
+		return fmt.Errorf("failed uploading coverage: %w", err)
+	}
+	appCtx.Logger.Info("uploaded coverage", "url", resultURL, "files", len(files))
+
+	return nil
+}
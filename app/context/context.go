@@ -21,6 +21,9 @@ type Context struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
+	// IsStdoutTTY is true if Stdout is a terminal, which enables colored
+	// output.
+	IsStdoutTTY bool
 
 	// Metadata
 	Version *VersionInfo
@@ -58,6 +58,7 @@ func WithLogger(isStdoutTTY, isStderrTTY bool) Option {
 		)
 		app.logLevel = lvl
 		app.ctx.Logger = logger
+		app.ctx.IsStdoutTTY = isStdoutTTY
 		slog.SetDefault(logger)
 	}
 }
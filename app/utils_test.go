@@ -9,9 +9,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/mandelsoft/vfs/pkg/memoryfs"
-
 	actx "go.hackfix.me/fcov/app/context"
+	"go.hackfix.me/fcov/internal/testfs"
 )
 
 type testApp struct {
@@ -32,7 +31,7 @@ func newTestApp(ctx context.Context, options ...Option) (*testApp, error) {
 	opts := []Option{
 		WithContext(ctx),
 		WithFDs(stdinR, stdoutW, stderrW),
-		WithFS(memoryfs.New()),
+		WithFS(testfs.New()),
 		WithLogger(false, false),
 		WithEnv(env),
 	}
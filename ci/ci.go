@@ -0,0 +1,261 @@
+// Package ci detects the continuous integration environment fcov is running
+// in, and extracts information about the build from it.
+package ci
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Env is the interface to the process environment.
+type Env interface {
+	Get(string) string
+}
+
+// Provider is a supported CI provider.
+type Provider string
+
+// Supported CI providers.
+const (
+	GitHubActions Provider = "github-actions"
+	GitLab        Provider = "gitlab"
+	Buildkite     Provider = "buildkite"
+	Jenkins       Provider = "jenkins"
+	CircleCI      Provider = "circleci"
+	Drone         Provider = "drone"
+)
+
+// Info is the information about the build extracted from the CI environment.
+// Any values that can't be determined are left empty.
+type Info struct {
+	Provider Provider
+	// ServerURL is the base URL of the code host, e.g. "https://github.com".
+	ServerURL string
+	// Repository is the repository name, e.g. "hackfixme/fcov".
+	Repository string
+	// Commit is the commit SHA being built.
+	Commit string
+	// Branch is the branch being built. For pull requests, this is the source
+	// branch.
+	Branch string
+	// PullRequest is the number of the pull or merge request being built, or
+	// 0 if the build isn't for a pull request.
+	PullRequest int
+	// BaseRef is the target branch of the pull request.
+	BaseRef string
+	// BuildURL is the link to the build in the CI provider's web UI.
+	BuildURL string
+}
+
+// LinkTemplate returns the template of links to source files on the code host
+// of the provider, for use as report.RenderOptions.LinkTemplate. It returns an
+// empty string if the code host is unknown.
+func (i Info) LinkTemplate() string {
+	if i.ServerURL == "" {
+		return ""
+	}
+
+	switch i.Provider {
+	case GitHubActions:
+		return i.ServerURL + "/{repo}/blob/{sha}/{path}#L{line}"
+	case GitLab:
+		return i.ServerURL + "/{repo}/-/blob/{sha}/{path}#L{line}"
+	case Buildkite, Jenkins, CircleCI, Drone:
+		// The code host depends on the repository URL.
+		if strings.Contains(i.ServerURL, "gitlab") {
+			return i.ServerURL + "/{repo}/-/blob/{sha}/{path}#L{line}"
+		}
+		return i.ServerURL + "/{repo}/blob/{sha}/{path}#L{line}"
+	}
+
+	return ""
+}
+
+// Detect returns the information about the build from the CI environment,
+// and false if no supported CI provider is detected.
+func Detect(env Env) (Info, bool) {
+	switch {
+	case env.Get("GITHUB_ACTIONS") == "true":
+		return detectGitHubActions(env), true
+	case env.Get("GITLAB_CI") != "":
+		return detectGitLab(env), true
+	case env.Get("BUILDKITE") == "true":
+		return detectBuildkite(env), true
+	case env.Get("CIRCLECI") == "true":
+		return detectCircleCI(env), true
+	case env.Get("DRONE") == "true":
+		return detectDrone(env), true
+	case env.Get("JENKINS_URL") != "":
+		return detectJenkins(env), true
+	}
+
+	return Info{}, false
+}
+
+// See https://docs.github.com/en/actions/reference/variables-reference
+func detectGitHubActions(env Env) Info {
+	info := Info{
+		Provider:   GitHubActions,
+		ServerURL:  env.Get("GITHUB_SERVER_URL"),
+		Repository: env.Get("GITHUB_REPOSITORY"),
+		Commit:     env.Get("GITHUB_SHA"),
+		Branch:     env.Get("GITHUB_HEAD_REF"),
+		BaseRef:    env.Get("GITHUB_BASE_REF"),
+	}
+
+	// GITHUB_REF is in the form of refs/pull/<number>/merge for pull requests.
+	if pr, ok := strings.CutPrefix(env.Get("GITHUB_REF"), "refs/pull/"); ok {
+		info.PullRequest = atoi(strings.TrimSuffix(pr, "/merge"))
+	}
+	if info.Branch == "" && env.Get("GITHUB_REF_TYPE") == "branch" {
+		info.Branch = env.Get("GITHUB_REF_NAME")
+	}
+	if runID := env.Get("GITHUB_RUN_ID"); runID != "" && info.ServerURL != "" {
+		info.BuildURL = info.ServerURL + "/" + info.Repository + "/actions/runs/" + runID
+	}
+
+	return info
+}
+
+// See https://docs.gitlab.com/ci/variables/predefined_variables/
+func detectGitLab(env Env) Info {
+	info := Info{
+		Provider:    GitLab,
+		ServerURL:   env.Get("CI_SERVER_URL"),
+		Repository:  env.Get("CI_PROJECT_PATH"),
+		Commit:      env.Get("CI_COMMIT_SHA"),
+		Branch:      env.Get("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"),
+		PullRequest: atoi(env.Get("CI_MERGE_REQUEST_IID")),
+		BaseRef:     env.Get("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+		BuildURL:    env.Get("CI_JOB_URL"),
+	}
+	if info.Branch == "" {
+		info.Branch = env.Get("CI_COMMIT_BRANCH")
+	}
+
+	return info
+}
+
+// See https://buildkite.com/docs/pipelines/configure/environment-variables
+func detectBuildkite(env Env) Info {
+	info := Info{
+		Provider:    Buildkite,
+		Commit:      env.Get("BUILDKITE_COMMIT"),
+		Branch:      env.Get("BUILDKITE_BRANCH"),
+		PullRequest: atoi(env.Get("BUILDKITE_PULL_REQUEST")), // "false" if not a PR
+		BaseRef:     env.Get("BUILDKITE_PULL_REQUEST_BASE_BRANCH"),
+		BuildURL:    env.Get("BUILDKITE_BUILD_URL"),
+	}
+	info.ServerURL, info.Repository = parseRepoURL(env.Get("BUILDKITE_REPO"))
+
+	return info
+}
+
+// See https://circleci.com/docs/reference/variables/
+func detectCircleCI(env Env) Info {
+	info := Info{
+		Provider:    CircleCI,
+		Commit:      env.Get("CIRCLE_SHA1"),
+		Branch:      env.Get("CIRCLE_BRANCH"),
+		PullRequest: atoi(env.Get("CIRCLE_PR_NUMBER")),
+		BuildURL:    env.Get("CIRCLE_BUILD_URL"),
+	}
+	info.ServerURL, info.Repository = parseRepoURL(env.Get("CIRCLE_REPOSITORY_URL"))
+	if info.Repository == "" && env.Get("CIRCLE_PROJECT_USERNAME") != "" {
+		info.Repository = env.Get("CIRCLE_PROJECT_USERNAME") + "/" + env.Get("CIRCLE_PROJECT_REPONAME")
+	}
+	if info.PullRequest == 0 {
+		// CIRCLE_PULL_REQUEST is the URL of the pull request.
+		prURL := env.Get("CIRCLE_PULL_REQUEST")
+		info.PullRequest = atoi(prURL[strings.LastIndex(prURL, "/")+1:])
+	}
+
+	return info
+}
+
+// See https://docs.drone.io/pipeline/environment/reference/
+func detectDrone(env Env) Info {
+	info := Info{
+		Provider:    Drone,
+		Repository:  env.Get("DRONE_REPO"),
+		Commit:      env.Get("DRONE_COMMIT_SHA"),
+		Branch:      env.Get("DRONE_SOURCE_BRANCH"),
+		PullRequest: atoi(env.Get("DRONE_PULL_REQUEST")),
+		BaseRef:     env.Get("DRONE_TARGET_BRANCH"),
+		BuildURL:    env.Get("DRONE_BUILD_LINK"),
+	}
+	if info.Branch == "" {
+		info.Branch = env.Get("DRONE_BRANCH")
+	}
+	if info.PullRequest == 0 {
+		// DRONE_TARGET_BRANCH is also set for regular builds.
+		info.BaseRef = ""
+	}
+	if u, err := url.Parse(env.Get("DRONE_REPO_LINK")); err == nil && u.Host != "" {
+		info.ServerURL = u.Scheme + "://" + u.Host
+	}
+
+	return info
+}
+
+// See https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+// The Git variables are set by the Git plugin, and the CHANGE_* variables by
+// multibranch pipelines.
+func detectJenkins(env Env) Info {
+	info := Info{
+		Provider:    Jenkins,
+		Commit:      env.Get("GIT_COMMIT"),
+		Branch:      env.Get("CHANGE_BRANCH"),
+		PullRequest: atoi(env.Get("CHANGE_ID")),
+		BaseRef:     env.Get("CHANGE_TARGET"),
+		BuildURL:    env.Get("BUILD_URL"),
+	}
+	if info.Branch == "" {
+		info.Branch = env.Get("BRANCH_NAME")
+	}
+	if info.Branch == "" {
+		info.Branch = strings.TrimPrefix(env.Get("GIT_BRANCH"), "origin/")
+	}
+	info.ServerURL, info.Repository = parseRepoURL(env.Get("GIT_URL"))
+
+	return info
+}
+
+// parseRepoURL returns the HTTPS server URL and the repository name from a Git
+// remote URL in either SCP-like or URL syntax, e.g.
+// "git@github.com:hackfixme/fcov.git" or "https://github.com/hackfixme/fcov".
+func parseRepoURL(repoURL string) (serverURL, repo string) {
+	if repoURL == "" {
+		return "", ""
+	}
+
+	var host, path string
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			host = u.Host
+		}
+	} else if at := strings.Index(repoURL, "@"); at >= 0 {
+		// SCP-like syntax: user@host:path
+		host, path, _ = strings.Cut(repoURL[at+1:], ":")
+	} else {
+		return "", ""
+	}
+
+	repo = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || repo == "" {
+		return "", ""
+	}
+
+	return "https://" + host, repo
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
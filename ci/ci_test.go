@@ -0,0 +1,236 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapEnv map[string]string
+
+func (e mapEnv) Get(key string) string { return e[key] }
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	const sha = "0123456789abcdef"
+
+	testCases := []struct {
+		name    string
+		env     mapEnv
+		expInfo Info
+		expOK   bool
+	}{
+		{
+			name: "none",
+			env:  mapEnv{"CI": "true"},
+		},
+		{
+			name: "github_actions/pull_request",
+			env: mapEnv{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "hackfixme/fcov",
+				"GITHUB_SHA":        sha,
+				"GITHUB_REF":        "refs/pull/42/merge",
+				"GITHUB_REF_NAME":   "42/merge",
+				"GITHUB_REF_TYPE":   "branch",
+				"GITHUB_HEAD_REF":   "feature",
+				"GITHUB_BASE_REF":   "main",
+				"GITHUB_RUN_ID":     "1234",
+			},
+			expInfo: Info{
+				Provider: GitHubActions, ServerURL: "https://github.com",
+				Repository: "hackfixme/fcov", Commit: sha, Branch: "feature",
+				PullRequest: 42, BaseRef: "main",
+				BuildURL: "https://github.com/hackfixme/fcov/actions/runs/1234",
+			},
+			expOK: true,
+		},
+		{
+			name: "github_actions/push",
+			env: mapEnv{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REPOSITORY": "hackfixme/fcov",
+				"GITHUB_SHA":        sha,
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_REF_NAME":   "main",
+				"GITHUB_REF_TYPE":   "branch",
+			},
+			expInfo: Info{
+				Provider: GitHubActions, Repository: "hackfixme/fcov",
+				Commit: sha, Branch: "main",
+			},
+			expOK: true,
+		},
+		{
+			name: "gitlab/merge_request",
+			env: mapEnv{
+				"GITLAB_CI":                           "true",
+				"CI_SERVER_URL":                       "https://gitlab.example.com",
+				"CI_PROJECT_PATH":                     "group/project",
+				"CI_COMMIT_SHA":                       sha,
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_JOB_URL":                          "https://gitlab.example.com/group/project/-/jobs/1",
+			},
+			expInfo: Info{
+				Provider: GitLab, ServerURL: "https://gitlab.example.com",
+				Repository: "group/project", Commit: sha, Branch: "feature",
+				PullRequest: 7, BaseRef: "main",
+				BuildURL: "https://gitlab.example.com/group/project/-/jobs/1",
+			},
+			expOK: true,
+		},
+		{
+			name: "gitlab/branch",
+			env: mapEnv{
+				"GITLAB_CI":        "true",
+				"CI_COMMIT_SHA":    sha,
+				"CI_COMMIT_BRANCH": "main",
+			},
+			expInfo: Info{Provider: GitLab, Commit: sha, Branch: "main"},
+			expOK:   true,
+		},
+		{
+			name: "buildkite",
+			env: mapEnv{
+				"BUILDKITE":                          "true",
+				"BUILDKITE_REPO":                     "git@github.com:hackfixme/fcov.git",
+				"BUILDKITE_COMMIT":                   sha,
+				"BUILDKITE_BRANCH":                   "feature",
+				"BUILDKITE_PULL_REQUEST":             "12",
+				"BUILDKITE_PULL_REQUEST_BASE_BRANCH": "main",
+				"BUILDKITE_BUILD_URL":                "https://buildkite.com/org/pipeline/builds/1",
+			},
+			expInfo: Info{
+				Provider: Buildkite, ServerURL: "https://github.com",
+				Repository: "hackfixme/fcov", Commit: sha, Branch: "feature",
+				PullRequest: 12, BaseRef: "main",
+				BuildURL: "https://buildkite.com/org/pipeline/builds/1",
+			},
+			expOK: true,
+		},
+		{
+			name: "buildkite/no_pull_request",
+			env: mapEnv{
+				"BUILDKITE":              "true",
+				"BUILDKITE_PULL_REQUEST": "false",
+			},
+			expInfo: Info{Provider: Buildkite},
+			expOK:   true,
+		},
+		{
+			name: "circleci",
+			env: mapEnv{
+				"CIRCLECI":              "true",
+				"CIRCLE_REPOSITORY_URL": "https://github.com/hackfixme/fcov",
+				"CIRCLE_SHA1":           sha,
+				"CIRCLE_BRANCH":         "feature",
+				"CIRCLE_PULL_REQUEST":   "https://github.com/hackfixme/fcov/pull/5",
+				"CIRCLE_BUILD_URL":      "https://circleci.com/gh/hackfixme/fcov/1",
+			},
+			expInfo: Info{
+				Provider: CircleCI, ServerURL: "https://github.com",
+				Repository: "hackfixme/fcov", Commit: sha, Branch: "feature",
+				PullRequest: 5, BuildURL: "https://circleci.com/gh/hackfixme/fcov/1",
+			},
+			expOK: true,
+		},
+		{
+			name: "drone",
+			env: mapEnv{
+				"DRONE":               "true",
+				"DRONE_REPO":          "hackfixme/fcov",
+				"DRONE_REPO_LINK":     "https://gitea.example.com/hackfixme/fcov",
+				"DRONE_COMMIT_SHA":    sha,
+				"DRONE_BRANCH":        "main",
+				"DRONE_SOURCE_BRANCH": "feature",
+				"DRONE_TARGET_BRANCH": "main",
+				"DRONE_PULL_REQUEST":  "3",
+				"DRONE_BUILD_LINK":    "https://drone.example.com/hackfixme/fcov/1",
+			},
+			expInfo: Info{
+				Provider: Drone, ServerURL: "https://gitea.example.com",
+				Repository: "hackfixme/fcov", Commit: sha, Branch: "feature",
+				PullRequest: 3, BaseRef: "main",
+				BuildURL: "https://drone.example.com/hackfixme/fcov/1",
+			},
+			expOK: true,
+		},
+		{
+			name: "jenkins",
+			env: mapEnv{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"GIT_URL":     "ssh://git@gitlab.example.com:2222/group/project.git",
+				"GIT_COMMIT":  sha,
+				"GIT_BRANCH":  "origin/main",
+				"BUILD_URL":   "https://jenkins.example.com/job/project/1/",
+			},
+			expInfo: Info{
+				Provider: Jenkins, ServerURL: "https://gitlab.example.com",
+				Repository: "group/project", Commit: sha, Branch: "main",
+				BuildURL: "https://jenkins.example.com/job/project/1/",
+			},
+			expOK: true,
+		},
+		{
+			name: "jenkins/multibranch_pull_request",
+			env: mapEnv{
+				"JENKINS_URL":   "https://jenkins.example.com/",
+				"GIT_COMMIT":    sha,
+				"BRANCH_NAME":   "PR-9",
+				"CHANGE_ID":     "9",
+				"CHANGE_BRANCH": "feature",
+				"CHANGE_TARGET": "main",
+			},
+			expInfo: Info{
+				Provider: Jenkins, Commit: sha, Branch: "feature",
+				PullRequest: 9, BaseRef: "main",
+			},
+			expOK: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			info, ok := Detect(tc.env)
+			assert.Equal(t, tc.expOK, ok)
+			assert.Equal(t, tc.expInfo, info)
+		})
+	}
+}
+
+func TestInfoLinkTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Provider: GitHubActions}, ""},
+		{
+			Info{Provider: GitHubActions, ServerURL: "https://github.com"},
+			"https://github.com/{repo}/blob/{sha}/{path}#L{line}",
+		},
+		{
+			Info{Provider: GitLab, ServerURL: "https://git.example.com"},
+			"https://git.example.com/{repo}/-/blob/{sha}/{path}#L{line}",
+		},
+		{
+			Info{Provider: Jenkins, ServerURL: "https://gitlab.example.com"},
+			"https://gitlab.example.com/{repo}/-/blob/{sha}/{path}#L{line}",
+		},
+		{
+			Info{Provider: Drone, ServerURL: "https://gitea.example.com"},
+			"https://gitea.example.com/{repo}/blob/{sha}/{path}#L{line}",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.info.LinkTemplate())
+	}
+}
@@ -0,0 +1,221 @@
+// Package diff parses unified diffs, such as the output of 'git diff', into
+// the lines that were added or modified in each file.
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Range is a range of lines in a file, inclusive of both ends.
+type Range struct {
+	Start, End int
+}
+
+// Changes maps the paths of changed files to the ranges of lines that were
+// added or modified in them, sorted by line. Files that were changed without
+// adding lines, e.g. by only removing lines, are present with no ranges.
+// Deleted files are not present.
+type Changes map[string][]Range
+
+// Changed returns true if the file at path was changed.
+func (c Changes) Changed(path string) bool {
+	_, ok := c[path]
+	return ok
+}
+
+// Intersect returns the changed ranges of lines of the file at path that are
+// between the lines start and end, inclusive, clipped to them.
+func (c Changes) Intersect(path string, start, end int) []Range {
+	var ranges []Range
+	for _, r := range c[path] {
+		if r.End < start || r.Start > end {
+			continue
+		}
+		ranges = append(ranges, Range{Start: max(r.Start, start), End: min(r.End, end)})
+	}
+
+	return ranges
+}
+
+// Parse reads a unified diff of one or more files, in the format of
+// 'git diff' or of the Bitbucket Data Center pull request diff, and returns the
+// changed lines of each file.
+func Parse(r io.Reader) (Changes, error) {
+	changes := make(Changes)
+	var (
+		p    parser
+		path string
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if p.inHunk() {
+			if err := p.parseLine(text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if path != "" {
+				changes[path] = p.ranges
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			path, p = "", parser{}
+		case strings.HasPrefix(text, "rename to "):
+			// Renamed files don't have a '+++' line if their content is unchanged.
+			path = strings.TrimPrefix(text, "rename to ")
+			changes[path] = nil
+		case strings.HasPrefix(text, "+++ "):
+			path = filePath(strings.TrimPrefix(text, "+++ "))
+			if path != "" {
+				changes[path] = nil
+			}
+			p = parser{}
+		case strings.HasPrefix(text, "@@ "):
+			if err := p.parseHeader(text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading diff: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ParseHunks parses the hunks of the unified diff of a single file, without
+// the file header lines, such as the patches returned by the GitHub API. It
+// returns the ranges of added or modified lines.
+func ParseHunks(patch string) ([]Range, error) {
+	var p parser
+	for i, text := range strings.Split(patch, "\n") {
+		var err error
+		if p.inHunk() {
+			err = p.parseLine(text)
+		} else if strings.HasPrefix(text, "@@ ") {
+			err = p.parseHeader(text)
+		} else if text != "" {
+			err = fmt.Errorf("line outside of a hunk: %s", text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	return p.ranges, nil
+}
+
+// filePath returns the path of the file in a '+++' header line, or an empty
+// string if the file was deleted.
+func filePath(header string) string {
+	// Some tools append a tab and the modification time.
+	header, _, _ = strings.Cut(header, "\t")
+	if header == "/dev/null" {
+		return ""
+	}
+	// Git uses the 'b/' prefix, and Bitbucket Data Center 'dst://'.
+	for _, prefix := range []string{"b/", "dst://"} {
+		if path, ok := strings.CutPrefix(header, prefix); ok {
+			return path
+		}
+	}
+
+	return header
+}
+
+// parser tracks the position in the hunks of a file.
+type parser struct {
+	// oldLeft and newLeft are the amount of lines of the old and new file that
+	// are left in the current hunk.
+	oldLeft, newLeft int
+	// line is the number of the next line of the new file.
+	line   int
+	ranges []Range
+}
+
+func (p *parser) inHunk() bool {
+	return p.oldLeft > 0 || p.newLeft > 0
+}
+
+// parseHeader parses a hunk header in the form of
+// '@@ -<start>[,<count>] +<start>[,<count>] @@'.
+func (p *parser) parseHeader(text string) error {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || fields[3] != "@@" {
+		return fmt.Errorf("invalid hunk header: %s", text)
+	}
+
+	var okOld, okNew bool
+	_, p.oldLeft, okOld = parseHunkRange(fields[1], "-")
+	p.line, p.newLeft, okNew = parseHunkRange(fields[2], "+")
+	if !okOld || !okNew {
+		return fmt.Errorf("invalid hunk header: %s", text)
+	}
+
+	return nil
+}
+
+// parseLine parses a line of a hunk.
+func (p *parser) parseLine(text string) error {
+	switch {
+	case strings.HasPrefix(text, "+"):
+		p.add(p.line)
+		p.line++
+		p.newLeft--
+	case strings.HasPrefix(text, "-"):
+		p.oldLeft--
+	case strings.HasPrefix(text, " "), text == "":
+		// Some tools strip the trailing space of empty context lines.
+		p.line++
+		p.oldLeft--
+		p.newLeft--
+	case strings.HasPrefix(text, `\`):
+		// "\ No newline at end of file"
+	default:
+		return fmt.Errorf("invalid hunk line: %s", text)
+	}
+	if p.oldLeft < 0 || p.newLeft < 0 {
+		return fmt.Errorf("hunk is longer than its header: %s", text)
+	}
+
+	return nil
+}
+
+// add adds the line to the changed ranges, extending the last range if the
+// line follows it.
+func (p *parser) add(line int) {
+	if n := len(p.ranges); n > 0 && p.ranges[n-1].End == line-1 {
+		p.ranges[n-1].End = line
+		return
+	}
+	p.ranges = append(p.ranges, Range{Start: line, End: line})
+}
+
+// parseHunkRange parses a hunk range in the form of '<prefix><start>[,<count>]'.
+// It returns false if the range is invalid.
+func parseHunkRange(s, prefix string) (start, count int, ok bool) {
+	s, ok = strings.CutPrefix(s, prefix)
+	if !ok {
+		return 0, 0, false
+	}
+	count = 1
+	startStr, countStr, found := strings.Cut(s, ",")
+	var err error
+	if found {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	if start, err = strconv.Atoi(startStr); err != nil {
+		return 0, 0, false
+	}
+
+	return start, count, true
+}
@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		diff       string
+		expChanges Changes
+		expErr     string
+	}{
+		{
+			name: "ok/modified_added_deleted",
+			diff: `diff --git a/pkg/file.go b/pkg/file.go
+index 1234567..89abcde 100644
+--- a/pkg/file.go
++++ b/pkg/file.go
+@@ -10,4 +10,6 @@ func f() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	return
++
+ }
+@@ -30 +32,2 @@
+-x
++--- y
++z
+diff --git a/pkg/new.go b/pkg/new.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/new.go
+@@ -0,0 +1,2 @@
++package pkg
++
+\ No newline at end of file
+diff --git a/pkg/old.go b/pkg/old.go
+deleted file mode 100644
+--- a/pkg/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package pkg
+-
+diff --git a/pkg/trimmed.go b/pkg/trimmed.go
+--- a/pkg/trimmed.go
++++ b/pkg/trimmed.go
+@@ -5,2 +5 @@
+-a
+-b
++c
+`,
+			expChanges: Changes{
+				"pkg/file.go":    {{Start: 11, End: 12}, {Start: 14, End: 14}, {Start: 32, End: 33}},
+				"pkg/new.go":     {{Start: 1, End: 2}},
+				"pkg/trimmed.go": {{Start: 5, End: 5}},
+			},
+		},
+		{
+			name: "ok/bitbucket_prefixes",
+			diff: `diff --git src://pkg/file.go dst://pkg/file.go
+--- src://pkg/file.go
++++ dst://pkg/file.go
+@@ -3,0 +4,2 @@
++a
++b
+`,
+			expChanges: Changes{"pkg/file.go": {{Start: 4, End: 5}}},
+		},
+		{
+			name: "ok/removed_lines_only",
+			diff: `diff --git a/pkg/file.go b/pkg/file.go
+--- a/pkg/file.go
++++ b/pkg/file.go
+@@ -3,2 +2,0 @@
+-a
+-b
+`,
+			expChanges: Changes{"pkg/file.go": nil},
+		},
+		{
+			name: "ok/renamed",
+			diff: `diff --git a/pkg/a.go b/pkg/b.go
+similarity index 100%
+rename from pkg/a.go
+rename to pkg/b.go
+`,
+			expChanges: Changes{"pkg/b.go": nil},
+		},
+		{
+			name:       "ok/empty",
+			diff:       "",
+			expChanges: Changes{},
+		},
+		{
+			name: "err/invalid_header",
+			diff: `+++ b/pkg/file.go
+@@ -a +1 @@
+`,
+			expErr: "line 2: invalid hunk header: @@ -a +1 @@",
+		},
+		{
+			name: "err/invalid_line",
+			diff: `+++ b/pkg/file.go
+@@ -1 +1 @@
+?
+`,
+			expErr: "line 3: invalid hunk line: ?",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			changes, err := Parse(strings.NewReader(tt.diff))
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expChanges, changes)
+		})
+	}
+}
+
+func TestParseHunks(t *testing.T) {
+	t.Parallel()
+
+	ranges, err := ParseHunks("@@ -1,3 +1,5 @@\n package pkg\n+\n+import \"fmt\"\n \n-var x\n+var y\n@@ -20 +21 @@ func f()\n-a\n+b")
+	require.NoError(t, err)
+	assert.Equal(t, []Range{{Start: 2, End: 3}, {Start: 5, End: 5}, {Start: 21, End: 21}}, ranges)
+
+	ranges, err = ParseHunks("")
+	require.NoError(t, err)
+	assert.Empty(t, ranges)
+
+	_, err = ParseHunks("@@ -1 +1 @@\n a\n+b")
+	require.EqualError(t, err, "line 3: line outside of a hunk: +b")
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	changes := Changes{
+		"file.go":    {{Start: 3, End: 5}, {Start: 10, End: 12}, {Start: 20, End: 20}},
+		"removed.go": nil,
+	}
+
+	assert.True(t, changes.Changed("file.go"))
+	assert.True(t, changes.Changed("removed.go"))
+	assert.False(t, changes.Changed("other.go"))
+
+	assert.Equal(t, []Range{{Start: 4, End: 5}, {Start: 10, End: 11}}, changes.Intersect("file.go", 4, 11))
+	assert.Equal(t, []Range{{Start: 20, End: 20}}, changes.Intersect("file.go", 15, 30))
+	assert.Empty(t, changes.Intersect("file.go", 6, 9))
+	assert.Empty(t, changes.Intersect("removed.go", 1, 100))
+	assert.Empty(t, changes.Intersect("other.go", 1, 100))
+}
@@ -0,0 +1,169 @@
+// Package history records the coverage of each run in an append-only history
+// file, and renders coverage trends from it.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"go.hackfix.me/fcov/internal/fsutil"
+	"go.hackfix.me/fcov/report"
+)
+
+// Entry is the coverage of a single run.
+type Entry struct {
+	Timestamp  time.Time        `json:"timestamp"`
+	Repository string           `json:"repository,omitempty"`
+	Commit     string           `json:"commit,omitempty"`
+	Branch     string           `json:"branch,omitempty"`
+	Total      Stats            `json:"total"`
+	Packages   map[string]Stats `json:"packages,omitempty"`
+}
+
+// Stats holds the coverage statistics of the total or a package.
+type Stats struct {
+	NumStatements int     `json:"num_statements"`
+	HitCount      int     `json:"hit_count"`
+	Coverage      float64 `json:"coverage"`
+}
+
+// NewEntry returns the history entry of the report. Package paths have
+// trimPackagePrefix removed.
+func NewEntry(r *report.Report, trimPackagePrefix string) Entry {
+	e := Entry{
+		Timestamp:  r.Metadata.Timestamp.UTC(),
+		Repository: r.Metadata.Repository,
+		Commit:     r.Metadata.Commit,
+		Branch:     r.Metadata.Branch,
+		Total:      Stats{r.NumStatements, r.HitCount, r.Coverage},
+		Packages:   make(map[string]Stats, len(r.Packages)),
+	}
+	for _, row := range r.StatsRows(report.RenderOptions{TrimPackagePrefix: trimPackagePrefix}) {
+		if row.Scope == report.ScopePackage {
+			e.Packages[row.Package] = Stats{row.NumStatements, row.HitCount, row.Coverage}
+		}
+	}
+
+	return e
+}
+
+// Retention limits the entries kept in the history file. Zero values disable
+// the respective limit.
+type Retention struct {
+	// MaxEntries is the maximum number of entries. The oldest entries are
+	// removed first.
+	MaxEntries int
+	// MaxAge is the maximum age of entries, relative to the newest entry.
+	MaxAge time.Duration
+}
+
+// Apply returns the entries that are within the retention limits. The entries
+// must be sorted from oldest to newest.
+func (r Retention) Apply(entries []Entry) []Entry {
+	if r.MaxAge > 0 && len(entries) > 0 {
+		cutoff := entries[len(entries)-1].Timestamp.Add(-r.MaxAge)
+		i := 0
+		for i < len(entries) && entries[i].Timestamp.Before(cutoff) {
+			i++
+		}
+		entries = entries[i:]
+	}
+	if r.MaxEntries > 0 && len(entries) > r.MaxEntries {
+		entries = entries[len(entries)-r.MaxEntries:]
+	}
+
+	return entries
+}
+
+// Load reads the entries of the history file at path, which contains an entry
+// encoded as JSON on each line, from oldest to newest. It returns no entries if
+// the file doesn't exist.
+func Load(fsys vfs.FileSystem, path string) ([]Entry, error) {
+	data, err := vfs.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading history file: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed decoding history entry on line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading history file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Append adds the entry to the end of the history file at path, creating the
+// file if it doesn't exist. Existing entries are never modified. If the
+// history exceeds the retention limits, the oldest entries are removed with
+// Prune.
+func Append(fsys vfs.FileSystem, path string, entry Entry, retention Retention) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed encoding history entry: %w", err)
+	}
+
+	if err = fsutil.AppendFile(fsys, path, append(line, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed appending to history file: %w", err)
+	}
+
+	if retention == (Retention{}) {
+		return nil
+	}
+
+	return Prune(fsys, path, retention)
+}
+
+// Prune removes the entries of the history file at path that are outside of
+// the retention limits. The remaining entries are written to a temporary file
+// that replaces the history file, so that it's never left truncated. The file
+// is not modified if no entries are removed.
+func Prune(fsys vfs.FileSystem, path string, retention Retention) error {
+	entries, err := Load(fsys, path)
+	if err != nil {
+		return err
+	}
+	kept := retention.Apply(entries)
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range kept {
+		if err = enc.Encode(e); err != nil {
+			return fmt.Errorf("failed encoding history entry: %w", err)
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err = vfs.WriteFile(fsys, tmpPath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed writing history file: %w", err)
+	}
+	if err = fsys.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed replacing history file: %w", err)
+	}
+
+	return nil
+}
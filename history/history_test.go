@@ -0,0 +1,110 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/internal/testfs"
+	"go.hackfix.me/fcov/report"
+	"go.hackfix.me/fcov/types"
+)
+
+func TestNewEntry(t *testing.T) {
+	t.Parallel()
+
+	r := &report.Report{
+		Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+		Packages: map[string]*report.Package{
+			"path/pkg1": {
+				Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+				Name:  "path/pkg1",
+				Files: map[string]*report.File{
+					"file1.go": {
+						Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+						Name:  "file1.go", Package: "path/pkg1",
+					},
+				},
+			},
+		},
+		Metadata: report.Metadata{
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Commit:    "abc123",
+			Branch:    "main",
+		},
+	}
+
+	assert.Equal(t, Entry{
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Commit:    "abc123",
+		Branch:    "main",
+		Total:     Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+		Packages:  map[string]Stats{"pkg1": {NumStatements: 4, HitCount: 3, Coverage: 0.75}},
+	}, NewEntry(r, "path/"))
+}
+
+func TestAppendOSFS(t *testing.T) {
+	t.Parallel()
+
+	fs := osfs.New()
+	path := vfs.Join(fs, t.TempDir(), "history.jsonl")
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	retention := Retention{MaxEntries: 2}
+	for d := 1; d <= 3; d++ {
+		require.NoError(t, Append(fs, path, Entry{Timestamp: day(d)}, retention))
+	}
+	entries, err := Load(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Timestamp: day(2)}, {Timestamp: day(3)}}, entries)
+}
+
+func TestAppend(t *testing.T) {
+	t.Parallel()
+
+	fs := testfs.New()
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	entries, err := Load(fs, "/history.jsonl")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	retention := Retention{MaxEntries: 3, MaxAge: 72 * time.Hour}
+	for d := 1; d <= 4; d++ {
+		entry := Entry{Timestamp: day(d), Total: Stats{Coverage: float64(d) / 10}}
+		require.NoError(t, Append(fs, "/history.jsonl", entry, retention))
+	}
+
+	data, err := vfs.ReadFile(fs, "/history.jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, `{"timestamp":"2025-01-02T00:00:00Z","total":{"num_statements":0,"hit_count":0,"coverage":0.2}}
+{"timestamp":"2025-01-03T00:00:00Z","total":{"num_statements":0,"hit_count":0,"coverage":0.3}}
+{"timestamp":"2025-01-04T00:00:00Z","total":{"num_statements":0,"hit_count":0,"coverage":0.4}}
+`, string(data))
+
+	// Entries older than the maximum age are removed.
+	entry := Entry{Timestamp: day(8), Total: Stats{Coverage: 0.8}}
+	require.NoError(t, Append(fs, "/history.jsonl", entry, retention))
+	entries, err = Load(fs, "/history.jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Timestamp: day(8), Total: Stats{Coverage: 0.8}}}, entries)
+	_, err = fs.Stat("/history.jsonl.tmp")
+	assert.True(t, vfs.IsNotExist(err))
+
+	// Without retention limits, existing lines are never read or rewritten.
+	require.NoError(t, vfs.WriteFile(fs, "/append.jsonl", []byte("existing\n"), 0o644))
+	require.NoError(t, Append(fs, "/append.jsonl", Entry{Timestamp: day(1)}, Retention{}))
+	data, err = vfs.ReadFile(fs, "/append.jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, "existing\n"+
+		`{"timestamp":"2025-01-01T00:00:00Z","total":{"num_statements":0,"hit_count":0,"coverage":0}}`+"\n",
+		string(data))
+
+	require.NoError(t, vfs.WriteFile(fs, "/invalid.jsonl", []byte("{}\n\nnot json\n"), 0o644))
+	_, err = Load(fs, "/invalid.jsonl")
+	require.ErrorContains(t, err, "failed decoding history entry on line 3: ")
+}
@@ -0,0 +1,267 @@
+package history
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Format is the type of format a history can be rendered in.
+type Format string
+
+// Supported formats.
+const (
+	// Text renders a table with the total coverage of each entry.
+	Text Format = "txt"
+	// Markdown renders a table with a sparkline of the total coverage and the
+	// coverage of each package.
+	Markdown Format = "md"
+	// SVG renders a line chart of the total coverage.
+	SVG Format = "svg"
+)
+
+// FormatFromString parses s into a Format value. It returns an empty Format if
+// s is not a supported format.
+func FormatFromString(s string) Format {
+	switch ft := Format(strings.TrimPrefix(s, ".")); ft {
+	case Text, Markdown, SVG:
+		return ft
+	default:
+		return ""
+	}
+}
+
+// Render the history entries, sorted from oldest to newest, in the provided
+// format.
+func Render(entries []Entry, ft Format) (string, error) {
+	switch ft {
+	case Text:
+		return renderText(entries), nil
+	case Markdown:
+		return renderMarkdown(entries), nil
+	case SVG:
+		return renderSVG(entries), nil
+	default:
+		return "", fmt.Errorf("unsupported history format: %s", ft)
+	}
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func renderText(entries []Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	buf := &strings.Builder{}
+	table := tablewriter.NewWriter(buf)
+	table.SetAutoWrapText(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT,
+	})
+	table.SetTablePadding(" ")
+	table.SetColumnSeparator("")
+	table.SetNoWhiteSpace(true)
+	table.SetBorder(false)
+
+	data := [][]string{{"Date", "Commit", "Coverage", "Change"}}
+	for i, e := range entries {
+		change := ""
+		if i > 0 {
+			change = formatChange(e.Total.Coverage - entries[i-1].Total.Coverage)
+		}
+		data = append(data, []string{
+			e.Timestamp.UTC().Format(timeLayout), shortCommit(e.Commit),
+			formatPercent(e.Total.Coverage), change,
+		})
+	}
+	table.AppendBulk(data)
+	table.Render()
+
+	first, last := entries[0].Total.Coverage, entries[len(entries)-1].Total.Coverage
+	fmt.Fprintf(buf, "\nTrend: %s %s -> %s (%s)", sparkline(totals(entries)),
+		formatPercent(first), formatPercent(last), formatChange(last-first))
+
+	return buf.String()
+}
+
+func renderMarkdown(entries []Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	buf.WriteString("| Package | Trend | Coverage | Change |\n")
+	buf.WriteString("| :------ | :---- | -------: | -----: |\n")
+
+	values := totals(entries)
+	fmt.Fprintf(&buf, "| **Total** | %s | %s | %s |\n", sparkline(values),
+		formatPercent(values[len(values)-1]), formatChange(values[len(values)-1]-values[0]))
+
+	// Only packages that are part of the newest entry are shown.
+	last := entries[len(entries)-1]
+	pkgNames := make([]string, 0, len(last.Packages))
+	for pkgName := range last.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkgName := range pkgNames {
+		values = values[:0]
+		for _, e := range entries {
+			if stats, ok := e.Packages[pkgName]; ok {
+				values = append(values, stats.Coverage)
+			}
+		}
+		fmt.Fprintf(&buf, "| `%s` | %s | %s | %s |\n", pkgName, sparkline(values),
+			formatPercent(values[len(values)-1]), formatChange(values[len(values)-1]-values[0]))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Dimensions of the SVG chart, and the margins around the plot area.
+const (
+	svgWidth, svgHeight   = 600, 240
+	svgMarginLeft         = 50
+	svgMarginRight        = 20
+	svgMarginTop          = 30
+	svgMarginBottom       = 40
+	svgPlotWidth          = svgWidth - svgMarginLeft - svgMarginRight
+	svgPlotHeight         = svgHeight - svgMarginTop - svgMarginBottom
+	svgFontFamily         = "Verdana,Geneva,DejaVu Sans,sans-serif"
+	svgLineColor          = "#4c1"
+	svgGridColor, svgText = "#ddd", "#555"
+)
+
+func renderSVG(entries []Entry) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" `+
+		`aria-label="Total coverage"><title>Total coverage</title>`+"\n", svgWidth, svgHeight)
+	fmt.Fprintf(&buf, `<g font-family="%s" font-size="11" fill="%s">`+"\n", svgFontFamily, svgText)
+	fmt.Fprintf(&buf, `<text x="%d" y="18" font-size="13">Total coverage</text>`+"\n", svgMarginLeft)
+
+	// Horizontal grid lines and labels of the Y axis, every 25%.
+	for pct := 0; pct <= 100; pct += 25 {
+		y := svgY(float64(pct) / 100)
+		fmt.Fprintf(&buf, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="%s"/>`+"\n",
+			svgMarginLeft, y, svgWidth-svgMarginRight, y, svgGridColor)
+		fmt.Fprintf(&buf, `<text x="%d" y="%.1f" text-anchor="end">%d%%</text>`+"\n",
+			svgMarginLeft-6, y+4, pct)
+	}
+
+	if len(entries) > 0 {
+		points := make([]string, len(entries))
+		for i, e := range entries {
+			points[i] = fmt.Sprintf("%.1f,%.1f", svgX(i, len(entries)), svgY(e.Total.Coverage))
+		}
+		fmt.Fprintf(&buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+			strings.Join(points, " "), svgLineColor)
+		for i, e := range entries {
+			title := e.Timestamp.UTC().Format(timeLayout)
+			if e.Commit != "" {
+				title += " " + shortCommit(e.Commit)
+			}
+			title += ": " + formatPercent(e.Total.Coverage)
+			fmt.Fprintf(&buf, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s</title></circle>`+"\n",
+				svgX(i, len(entries)), svgY(e.Total.Coverage), svgLineColor, html.EscapeString(title))
+		}
+
+		// Labels of the X axis, with the dates of the oldest and newest entries.
+		y := svgHeight - svgMarginBottom + 18
+		fmt.Fprintf(&buf, `<text x="%d" y="%d">%s</text>`+"\n",
+			svgMarginLeft, y, entries[0].Timestamp.UTC().Format("2006-01-02"))
+		if len(entries) > 1 {
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+				svgWidth-svgMarginRight, y, entries[len(entries)-1].Timestamp.UTC().Format("2006-01-02"))
+		}
+	}
+
+	buf.WriteString("</g></svg>")
+
+	return buf.String()
+}
+
+// svgX returns the X coordinate of the i-th of n entries. Entries are evenly
+// spaced, and a single entry is centered.
+func svgX(i, n int) float64 {
+	if n == 1 {
+		return svgMarginLeft + svgPlotWidth/2.0
+	}
+
+	return svgMarginLeft + float64(i)*svgPlotWidth/float64(n-1)
+}
+
+// svgY returns the Y coordinate of the coverage ratio cov.
+func svgY(cov float64) float64 {
+	return svgMarginTop + (1-cov)*svgPlotHeight
+}
+
+// sparkBars are the characters of a sparkline, from lowest to highest.
+const sparkBars = "▁▂▃▄▅▆▇█"
+
+// sparkline returns the values as a sparkline, scaled between the lowest and
+// highest value. If all values are equal, the bars are at half height.
+func sparkline(values []float64) string {
+	bars := []rune(sparkBars)
+	if len(values) == 0 {
+		return ""
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	var buf strings.Builder
+	for _, v := range values {
+		i := len(bars) / 2
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(bars)-1))
+		}
+		buf.WriteRune(bars[i])
+	}
+
+	return buf.String()
+}
+
+// totals returns the total coverage of each entry.
+func totals(entries []Entry) []float64 {
+	values := make([]float64, len(entries))
+	for i, e := range entries {
+		values[i] = e.Total.Coverage
+	}
+
+	return values
+}
+
+func formatPercent(cov float64) string {
+	return fmt.Sprintf("%.2f%%", cov*100)
+}
+
+// formatChange formats the difference between two coverage ratios in
+// percentage points, with an explicit sign.
+func formatChange(diff float64) string {
+	// Round first, so that tiny negative differences aren't shown as "-0.00%".
+	pct := math.Round(diff*10000) / 100
+	if pct == 0 {
+		pct = 0
+	}
+
+	return fmt.Sprintf("%+.2f%%", pct)
+}
+
+// shortCommit returns the first 10 characters of the commit SHA, like
+// report.Metadata does.
+func shortCommit(commit string) string {
+	if len(commit) > 10 {
+		return commit[:10]
+	}
+
+	return commit
+}
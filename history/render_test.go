@@ -0,0 +1,125 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	entries := []Entry{
+		{
+			Timestamp: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+			Commit:    "0123456789abcdef",
+			Total:     Stats{Coverage: 0.4},
+			Packages:  map[string]Stats{"pkg1": {Coverage: 0.5}, "old": {Coverage: 0.1}},
+		},
+		{
+			Timestamp: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC),
+			Commit:    "fedcba9876543210",
+			Total:     Stats{Coverage: 0.5},
+			Packages:  map[string]Stats{"pkg1": {Coverage: 0.5}, "pkg2": {Coverage: 0.2}},
+		},
+		{
+			Timestamp: time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC),
+			Total:     Stats{Coverage: 0.45},
+			Packages:  map[string]Stats{"pkg1": {Coverage: 0.5}, "pkg2": {Coverage: 0.3}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  Format
+		entries []Entry
+		want    string
+		expErr  string
+	}{
+		{
+			name:    "ok/text",
+			format:  Text,
+			entries: entries,
+			want: "Date                Commit     Coverage  Change \n" +
+				"2025-01-01 10:00:00 0123456789   40.00%         \n" +
+				"2025-01-02 10:00:00 fedcba9876   50.00% +10.00% \n" +
+				"2025-01-03 10:00:00              45.00%  -5.00% \n\n" +
+				"Trend: ▁█▄ 40.00% -> 45.00% (+5.00%)",
+		},
+		{
+			name:    "ok/markdown",
+			format:  Markdown,
+			entries: entries,
+			want: "| Package | Trend | Coverage | Change |\n" +
+				"| :------ | :---- | -------: | -----: |\n" +
+				"| **Total** | ▁█▄ | 45.00% | +5.00% |\n" +
+				"| `pkg1` | ▅▅▅ | 50.00% | +0.00% |\n" +
+				"| `pkg2` | ▁█ | 30.00% | +10.00% |",
+		},
+		{
+			name:    "ok/svg_single",
+			format:  SVG,
+			entries: entries[2:],
+			want: `<svg xmlns="http://www.w3.org/2000/svg" width="600" height="240" role="img" aria-label="Total coverage"><title>Total coverage</title>
+<g font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11" fill="#555">
+<text x="50" y="18" font-size="13">Total coverage</text>
+<line x1="50" y1="200.0" x2="580" y2="200.0" stroke="#ddd"/>
+<text x="44" y="204.0" text-anchor="end">0%</text>
+<line x1="50" y1="157.5" x2="580" y2="157.5" stroke="#ddd"/>
+<text x="44" y="161.5" text-anchor="end">25%</text>
+<line x1="50" y1="115.0" x2="580" y2="115.0" stroke="#ddd"/>
+<text x="44" y="119.0" text-anchor="end">50%</text>
+<line x1="50" y1="72.5" x2="580" y2="72.5" stroke="#ddd"/>
+<text x="44" y="76.5" text-anchor="end">75%</text>
+<line x1="50" y1="30.0" x2="580" y2="30.0" stroke="#ddd"/>
+<text x="44" y="34.0" text-anchor="end">100%</text>
+<polyline points="315.0,123.5" fill="none" stroke="#4c1" stroke-width="2"/>
+<circle cx="315.0" cy="123.5" r="3" fill="#4c1"><title>2025-01-03 10:00:00: 45.00%</title></circle>
+<text x="50" y="218">2025-01-03</text>
+</g></svg>`,
+		},
+		{
+			name:   "ok/empty",
+			format: Text,
+			want:   "",
+		},
+		{
+			name:   "err/format",
+			format: "html",
+			expErr: "unsupported history format: html",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Render(tt.entries, tt.format)
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("ok/svg", func(t *testing.T) {
+		t.Parallel()
+		got, err := Render(entries, SVG)
+		require.NoError(t, err)
+		assert.Contains(t, got, `<polyline points="50.0,132.0 315.0,115.0 580.0,123.5"`)
+		assert.Contains(t, got, `<title>2025-01-02 10:00:00 fedcba9876: 50.00%</title>`)
+		assert.Contains(t, got, `<text x="580" y="218" text-anchor="end">2025-01-03</text>`)
+	})
+}
+
+func TestFormatFromString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Markdown, FormatFromString("md"))
+	assert.Equal(t, SVG, FormatFromString(".svg"))
+	assert.Equal(t, Format(""), FormatFromString("json"))
+}
@@ -0,0 +1,29 @@
+// Package fsutil contains helpers for working with virtual filesystems.
+package fsutil
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// AppendFile writes data to the end of the file at path, which is created with
+// perm if it doesn't exist. The file is opened with O_APPEND, so that data
+// written by other processes, e.g. a CI runner, is never overwritten.
+func AppendFile(fsys vfs.FileSystem, path string, data []byte, perm os.FileMode) error {
+	f, err := fsys.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+	if err != nil {
+		return fmt.Errorf("failed opening file: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed writing file: %w", err)
+	}
+
+	return nil
+}
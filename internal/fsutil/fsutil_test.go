@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendFile(t *testing.T) {
+	t.Parallel()
+
+	fs := osfs.New()
+	path := filepath.Join(t.TempDir(), "file.txt")
+
+	require.NoError(t, AppendFile(fs, path, []byte("a\n"), 0o644))
+	require.NoError(t, AppendFile(fs, path, []byte("b\n"), 0o644))
+	data, err := vfs.ReadFile(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", string(data))
+
+	err = AppendFile(fs, filepath.Join(path, "file.txt"), []byte("c\n"), 0o644)
+	require.ErrorContains(t, err, "failed opening file: ")
+}
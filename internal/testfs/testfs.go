@@ -0,0 +1,65 @@
+// Package testfs provides the in-memory filesystem used by tests.
+package testfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// New returns an in-memory filesystem that supports the operations that
+// memoryfs gets wrong: opening non-empty files with O_APPEND, which fails
+// because memoryfs can't seek to the end of a file, and renaming over an
+// existing file, which fails instead of replacing it.
+func New() vfs.FileSystem {
+	return fileSystem{FileSystem: memoryfs.New()}
+}
+
+type fileSystem struct {
+	vfs.FileSystem
+}
+
+// OpenFile emulates O_APPEND by writing the existing content to the truncated
+// file, which leaves the file offset at its end.
+func (f fileSystem) OpenFile(name string, flags int, perm os.FileMode) (vfs.File, error) {
+	if flags&os.O_APPEND == 0 {
+		file, err := f.FileSystem.OpenFile(name, flags, perm)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening file: %w", err)
+		}
+		return file, nil
+	}
+
+	existing, err := vfs.ReadFile(f.FileSystem, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed reading file to append to: %w", err)
+	}
+	file, err := f.FileSystem.OpenFile(name, flags&^os.O_APPEND|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening file: %w", err)
+	}
+	if _, err = file.Write(existing); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed restoring file content: %w", err)
+	}
+
+	return file, nil
+}
+
+// Rename replaces newname if it exists, like os.Rename.
+func (f fileSystem) Rename(oldname, newname string) error {
+	if _, err := f.FileSystem.Stat(newname); err == nil {
+		if err = f.FileSystem.Remove(newname); err != nil {
+			return fmt.Errorf("failed removing rename target: %w", err)
+		}
+	}
+	if err := f.FileSystem.Rename(oldname, newname); err != nil {
+		return fmt.Errorf("failed renaming file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package bitbucket publishes coverage reports to Bitbucket Data Center.
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client is a minimal client of the Bitbucket Data Center REST API.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient returns a new Client that sends requests to the Bitbucket server
+// at baseURL, e.g. "https://bitbucket.example.com", authenticated with the
+// HTTP access token. If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+// APIError is an error response returned by the Bitbucket API.
+type APIError struct {
+	StatusCode int
+	Messages   []string
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Bitbucket API returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Messages) > 0 {
+		msg += ": " + strings.Join(e.Messages, "; ")
+	}
+
+	return msg
+}
+
+// do sends a request with the JSON encoded body to the API path, and decodes
+// the JSON response into out, if it's not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed encoding request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	resp, err := c.send(ctx, method, path, reqBody, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+// send sends a request with the JSON body to the API path, accepting a
+// response of the accept media type. It returns an *APIError if the response
+// status is not successful. The caller must close the response body.
+func (c *Client) send(
+	ctx context.Context, method, path string, body io.Reader, accept string,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed sending request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		// The errors are optional, so ignore decoding errors.
+		_ = json.NewDecoder(resp.Body).Decode(&errBody)
+		for _, e := range errBody.Errors {
+			apiErr.Messages = append(apiErr.Messages, e.Message)
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxAnnotations is the maximum amount of annotations a Code Insights report
+// can have.
+const MaxAnnotations = 1000
+
+// Result is the overall result of a Code Insights report.
+type Result string
+
+// Code Insights report results.
+const (
+	ResultPass Result = "PASS"
+	ResultFail Result = "FAIL"
+)
+
+// DataType is the type of the value of a report data field.
+type DataType string
+
+// Report data field types.
+const (
+	DataBoolean    DataType = "BOOLEAN"
+	DataDate       DataType = "DATE"
+	DataDuration   DataType = "DURATION"
+	DataLink       DataType = "LINK"
+	DataNumber     DataType = "NUMBER"
+	DataPercentage DataType = "PERCENTAGE"
+	DataText       DataType = "TEXT"
+)
+
+// Data is a field shown in the report.
+type Data struct {
+	Title string   `json:"title"`
+	Type  DataType `json:"type"`
+	Value any      `json:"value"`
+}
+
+// Report is a Code Insights report of a commit.
+type Report struct {
+	Title    string `json:"title"`
+	Details  string `json:"details,omitempty"`
+	Result   Result `json:"result,omitempty"`
+	Reporter string `json:"reporter,omitempty"`
+	Link     string `json:"link,omitempty"`
+	Data     []Data `json:"data,omitempty"`
+}
+
+// Annotation is a message associated with a line of a file, shown in the diff
+// of pull requests.
+type Annotation struct {
+	ExternalID string `json:"externalId,omitempty"`
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Message    string `json:"message"`
+	// Severity is one of "LOW", "MEDIUM" or "HIGH".
+	Severity string `json:"severity"`
+	// Type is one of "VULNERABILITY", "CODE_SMELL" or "BUG".
+	Type string `json:"type,omitempty"`
+	Link string `json:"link,omitempty"`
+}
+
+// PublishReport creates or replaces the Code Insights report with the given key
+// on the commit in repo, and replaces its annotations. The repo is in the form
+// of "<project key>/<repository slug>", or the path of an HTTP clone URL. Only
+// the first MaxAnnotations annotations are published.
+func (c *Client) PublishReport(
+	ctx context.Context, repo, commit, key string, report Report, annotations []Annotation,
+) error {
+	project, slug, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	reportPath := fmt.Sprintf("/rest/insights/1.0/projects/%s/repos/%s/commits/%s/reports/%s",
+		url.PathEscape(project), url.PathEscape(slug), url.PathEscape(commit), url.PathEscape(key))
+
+	if err := c.do(ctx, http.MethodPut, reportPath, report, nil); err != nil {
+		return fmt.Errorf("failed creating report: %w", err)
+	}
+
+	// Remove the annotations of a previous run of the same report.
+	if err := c.do(ctx, http.MethodDelete, reportPath+"/annotations", nil, nil); err != nil {
+		return fmt.Errorf("failed deleting annotations: %w", err)
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+	if len(annotations) > MaxAnnotations {
+		annotations = annotations[:MaxAnnotations]
+	}
+	req := map[string][]Annotation{"annotations": annotations}
+	if err := c.do(ctx, http.MethodPost, reportPath+"/annotations", req, nil); err != nil {
+		return fmt.Errorf("failed creating annotations: %w", err)
+	}
+
+	return nil
+}
+
+// splitRepo returns the project key and the repository slug of repo, which is
+// in the form of "<project key>/<repository slug>". The path of an HTTP clone
+// URL, in the form of "[<context path>/]scm/<project key>/<repository slug>",
+// is also accepted, since that's the repository detected from the CI
+// environment.
+func splitRepo(repo string) (project, slug string, err error) {
+	path := repo
+	if _, after, found := strings.Cut("/"+repo, "/scm/"); found {
+		path = after
+	}
+	project, slug, ok := strings.Cut(path, "/")
+	if !ok || project == "" || slug == "" || strings.Contains(slug, "/") {
+		return "", "", fmt.Errorf("invalid repository '%s': expected '<project key>/<repository slug>'", repo)
+	}
+
+	return project, slug, nil
+}
@@ -0,0 +1,124 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPublishReport(t *testing.T) {
+	t.Parallel()
+
+	const reportPath = "/rest/insights/1.0/projects/PROJ/repos/repo/commits/0123456789abcdef/reports/fcov"
+
+	var (
+		requests    []string
+		report      map[string]any
+		annotations map[string][]Annotation
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.Method {
+		case http.MethodPut:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		case http.MethodPost:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&annotations))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	anns := make([]Annotation, MaxAnnotations+10)
+	for i := range anns {
+		anns[i] = Annotation{
+			Path: "pkg/file.go", Line: i + 1, Severity: "LOW",
+			Message: fmt.Sprintf("Line %d is not covered by tests.", i+1),
+		}
+	}
+
+	client := NewClient(srv.URL+"/", "secret", srv.Client())
+	err := client.PublishReport(context.Background(), "PROJ/repo", "0123456789abcdef", "fcov",
+		Report{
+			Title: "Coverage", Result: ResultFail, Reporter: "fcov",
+			Data: []Data{{Title: "Total coverage", Type: DataPercentage, Value: 45.04}},
+		}, anns)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"PUT " + reportPath,
+		"DELETE " + reportPath + "/annotations",
+		"POST " + reportPath + "/annotations",
+	}, requests)
+	assert.Equal(t, map[string]any{
+		"title": "Coverage", "result": "FAIL", "reporter": "fcov",
+		"data": []any{
+			map[string]any{"title": "Total coverage", "type": "PERCENTAGE", "value": 45.04},
+		},
+	}, report)
+	assert.Len(t, annotations["annotations"], MaxAnnotations)
+	assert.Equal(t, anns[0], annotations["annotations"][0])
+}
+
+func TestClientPublishReportError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors": [{"message": "Invalid title"}, {"message": "Invalid data"}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	ctx := context.Background()
+	err := client.PublishReport(ctx, "PROJ/repo", "0123456789abcdef", "fcov", Report{}, nil)
+	assert.EqualError(t, err,
+		"failed creating report: Bitbucket API returned 400 Bad Request: Invalid title; Invalid data")
+
+	err = client.PublishReport(ctx, "repo", "0123456789abcdef", "fcov", Report{}, nil)
+	assert.EqualError(t, err,
+		"invalid repository 'repo': expected '<project key>/<repository slug>'")
+}
+
+func TestSplitRepo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		repo       string
+		expProject string
+		expSlug    string
+		expErr     string
+	}{
+		{repo: "PROJ/repo", expProject: "PROJ", expSlug: "repo"},
+		{repo: "~user/repo", expProject: "~user", expSlug: "repo"},
+		// The repository detected from a Data Center HTTP clone URL, e.g.
+		// https://bitbucket.example.com/scm/PROJ/repo.git
+		{repo: "scm/PROJ/repo", expProject: "PROJ", expSlug: "repo"},
+		{repo: "bitbucket/scm/PROJ/repo", expProject: "PROJ", expSlug: "repo"},
+		{repo: "repo", expErr: "invalid repository 'repo': expected '<project key>/<repository slug>'"},
+		{repo: "a/b/c", expErr: "invalid repository 'a/b/c': expected '<project key>/<repository slug>'"},
+		{repo: "scm/PROJ", expErr: "invalid repository 'scm/PROJ': expected '<project key>/<repository slug>'"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.repo, func(t *testing.T) {
+			t.Parallel()
+
+			project, slug, err := splitRepo(tt.repo)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expProject, project)
+			assert.Equal(t, tt.expSlug, slug)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.hackfix.me/fcov/diff"
+)
+
+// PullRequestChanges returns the files changed by the pull request pr in repo,
+// and the lines added or modified in them. The repo is in the form of
+// "<project key>/<repository slug>".
+func (c *Client) PullRequestChanges(ctx context.Context, repo string, pr int) (diff.Changes, error) {
+	project, slug, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d.diff?contextLines=0",
+		url.PathEscape(project), url.PathEscape(slug), pr)
+	resp, err := c.send(ctx, http.MethodGet, path, nil, "text/plain")
+	if err != nil {
+		return nil, fmt.Errorf("failed getting the diff of pull request %d: %w", pr, err)
+	}
+	defer resp.Body.Close()
+
+	changes, err := diff.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing the diff of pull request %d: %w", pr, err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,55 @@
+package bitbucket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/diff"
+)
+
+func TestClientPullRequestChanges(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.String())
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "text/plain", r.Header.Get("Accept"))
+		// Data Center prefixes paths with 'src://' and 'dst://'.
+		_, _ = w.Write([]byte("diff --git src://pkg/file.go dst://pkg/file.go\n" +
+			"--- src://pkg/file.go\n+++ dst://pkg/file.go\n@@ -10,0 +11,2 @@\n+a\n+b\n"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	changes, err := client.PullRequestChanges(context.Background(), "PROJ/repo", 42)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /rest/api/1.0/projects/PROJ/repos/repo/pull-requests/42.diff?contextLines=0",
+	}, requests)
+	assert.Equal(t, diff.Changes{"pkg/file.go": {{Start: 11, End: 12}}}, changes)
+}
+
+func TestClientPullRequestChangesError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors": [{"message": "Pull request 42 does not exist"}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	_, err := client.PullRequestChanges(context.Background(), "PROJ/repo", 42)
+	require.EqualError(t, err, "failed getting the diff of pull request 42: "+
+		"Bitbucket API returned 404 Not Found: Pull request 42 does not exist")
+
+	_, err = client.PullRequestChanges(context.Background(), "repo", 42)
+	require.EqualError(t, err, "invalid repository 'repo': expected '<project key>/<repository slug>'")
+}
@@ -0,0 +1,226 @@
+// Package codecov uploads coverage files to Codecov, or a compatible
+// self-hosted server, using the upload v4 protocol.
+package codecov
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// DefaultURL is the URL of the Codecov service.
+const DefaultURL = "https://codecov.io"
+
+// Client uploads coverage files to a Codecov server.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient returns a new Client that uploads to the server at baseURL,
+// authenticated with the repository upload token. If baseURL is empty,
+// DefaultURL is used. If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+// UploadOptions is the metadata sent along with an upload.
+type UploadOptions struct {
+	// Slug is the repository name, e.g. "hackfixme/fcov".
+	Slug        string
+	Commit      string
+	Branch      string
+	PullRequest int
+	// Flags group uploads in the Codecov UI, e.g. "unit" or "integration".
+	Flags []string
+	// Name is the name of the upload.
+	Name string
+	// Service is the name of the CI service, e.g. "github-actions".
+	Service  string
+	BuildURL string
+	// Version is the version of the uploader.
+	Version string
+}
+
+// File is a coverage file included in the upload.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// Payload returns the upload payload of the coverage files. The network is the
+// list of paths of files in the repository, which the server uses to fix the
+// paths in the coverage files.
+func Payload(network []string, files []File) []byte {
+	var buf bytes.Buffer
+	for _, path := range network {
+		buf.WriteString(path + "\n")
+	}
+	buf.WriteString("<<<<<< network\n")
+
+	for _, f := range files {
+		fmt.Fprintf(&buf, "# path=%s\n", f.Path)
+		buf.Write(f.Data)
+		if len(f.Data) > 0 && f.Data[len(f.Data)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("<<<<<< EOF\n")
+	}
+
+	return buf.Bytes()
+}
+
+// Upload sends the payload with the metadata in opts. The server first
+// responds with the URL of the results and the URL the payload should be
+// stored at, where the gzip compressed payload is then sent to. It returns the
+// URL of the results.
+func (c *Client) Upload(ctx context.Context, opts UploadOptions, payload []byte) (string, error) {
+	query := url.Values{}
+	query.Set("package", "fcov-"+opts.Version)
+	query.Set("slug", opts.Slug)
+	query.Set("commit", opts.Commit)
+	query.Set("branch", opts.Branch)
+	if opts.PullRequest > 0 {
+		query.Set("pr", strconv.Itoa(opts.PullRequest))
+	}
+	if len(opts.Flags) > 0 {
+		query.Set("flags", strings.Join(opts.Flags, ","))
+	}
+	for key, value := range map[string]string{
+		"name": opts.Name, "service": opts.Service, "build_url": opts.BuildURL,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/upload/v4?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed creating upload request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	body, err := c.send(req)
+	if err != nil {
+		return "", fmt.Errorf("failed requesting upload: %w", err)
+	}
+
+	resultURL, storeURL, ok := strings.Cut(strings.TrimSpace(string(body)), "\n")
+	if !ok || storeURL == "" {
+		return "", fmt.Errorf("invalid upload response: %q", body)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err = zw.Write(payload); err != nil {
+		return "", fmt.Errorf("failed compressing payload: %w", err)
+	}
+	if err = zw.Close(); err != nil {
+		return "", fmt.Errorf("failed compressing payload: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSpace(storeURL), &gz)
+	if err != nil {
+		return "", fmt.Errorf("failed creating store request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-gzip")
+	req.Header.Set("Content-Encoding", "gzip")
+	if _, err = c.send(req); err != nil {
+		return "", fmt.Errorf("failed storing payload: %w", err)
+	}
+
+	return strings.TrimSpace(resultURL), nil
+}
+
+// send sends the request, and returns the response body if it succeeded.
+func (c *Client) send(req *http.Request) ([]byte, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+
+	return body, nil
+}
+
+// APIError is an error response returned by the server.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Codecov returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+// Network returns the paths of the files under the root directory, relative
+// to it. Hidden directories, like .git, and vendored dependencies are skipped.
+func Network(fs vfs.FileSystem, root string) ([]string, error) {
+	var paths []string
+	err := vfs.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			name := info.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return vfs.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := vfs.Rel(fs, root, path)
+		if err != nil {
+			return err //nolint:wrapcheck // Wrapped below.
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing files in %s: %w", root, err)
+	}
+
+	return paths, nil
+}
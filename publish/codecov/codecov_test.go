@@ -0,0 +1,114 @@
+package codecov
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayload(t *testing.T) {
+	t.Parallel()
+
+	got := Payload([]string{"go.mod", "pkg/file.go"}, []File{
+		{Path: "coverage.txt", Data: []byte("mode: set\npkg/file.go:1.1,2.2 1 1\n")},
+		{Path: "integ.txt", Data: []byte("mode: set")},
+	})
+	assert.Equal(t, `go.mod
+pkg/file.go
+<<<<<< network
+# path=coverage.txt
+mode: set
+pkg/file.go:1.1,2.2 1 1
+<<<<<< EOF
+# path=integ.txt
+mode: set
+<<<<<< EOF
+`, string(got))
+}
+
+func TestNetwork(t *testing.T) {
+	t.Parallel()
+
+	fs := memoryfs.New()
+	for _, path := range []string{
+		"/repo/go.mod", "/repo/pkg/file.go", "/repo/.git/HEAD",
+		"/repo/vendor/dep/dep.go", "/repo/.github/workflows/ci.yml",
+	} {
+		require.NoError(t, fs.MkdirAll(vfs.Dir(fs, path), 0o755))
+		require.NoError(t, vfs.WriteFile(fs, path, nil, 0o644))
+	}
+
+	got, err := Network(fs, "/repo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.mod", "pkg/file.go"}, got)
+}
+
+func TestClientUpload(t *testing.T) {
+	t.Parallel()
+
+	var (
+		query  url.Values
+		stored string
+	)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("POST /upload/v4", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		query = r.URL.Query()
+		_, _ = w.Write([]byte("https://codecov.example.com/results/1\n" + srv.URL + "/store/1\n"))
+	})
+	mux.HandleFunc("PUT /store/1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		stored = string(data)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	resultURL, err := client.Upload(context.Background(), UploadOptions{
+		Slug: "org/repo", Commit: "0123456789abcdef", Branch: "feature", PullRequest: 42,
+		Flags: []string{"unit", "linux"}, Service: "github-actions", Version: "v1.2.3",
+	}, []byte("payload"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://codecov.example.com/results/1", resultURL)
+	assert.Equal(t, "payload", stored)
+	assert.Equal(t, url.Values{
+		"package": {"fcov-v1.2.3"},
+		"slug":    {"org/repo"},
+		"commit":  {"0123456789abcdef"},
+		"branch":  {"feature"},
+		"pr":      {"42"},
+		"flags":   {"unit,linux"},
+		"service": {"github-actions"},
+	}, query)
+}
+
+func TestClientUploadError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("Could not find a repository associated with upload token"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	_, err := client.Upload(context.Background(), UploadOptions{}, nil)
+	assert.EqualError(t, err, "failed requesting upload: Codecov returned 401 Unauthorized: "+
+		"Could not find a repository associated with upload token")
+}
@@ -0,0 +1,276 @@
+// Package coveralls publishes coverage to Coveralls, or a compatible
+// self-hosted server, using the jobs API.
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // Coveralls identifies sources by their MD5 digest.
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// DefaultURL is the URL of the Coveralls service.
+const DefaultURL = "https://coveralls.io"
+
+// Client posts coverage jobs to a Coveralls server.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient returns a new Client that posts to the server at baseURL,
+// authenticated with the repository token. If baseURL is empty, DefaultURL is
+// used. If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+// Job is a Coveralls job, i.e. the coverage of a single CI job. The repository
+// token is set by the Client.
+type Job struct {
+	RepoToken          string `json:"repo_token,omitempty"`
+	ServiceName        string `json:"service_name,omitempty"`
+	ServiceJobID       string `json:"service_job_id,omitempty"`
+	ServiceNumber      string `json:"service_number,omitempty"`
+	ServicePullRequest string `json:"service_pull_request,omitempty"`
+	ServiceJobURL      string `json:"service_job_url,omitempty"`
+	// Parallel marks the job as one of several jobs of the same build, whose
+	// coverage is merged once the build is marked as done with ParallelDone.
+	Parallel    bool         `json:"parallel,omitempty"`
+	FlagName    string       `json:"flag_name,omitempty"`
+	CommitSHA   string       `json:"commit_sha,omitempty"`
+	Git         *Git         `json:"git,omitempty"`
+	SourceFiles []SourceFile `json:"source_files"`
+}
+
+// Git is the Git information of a job.
+type Git struct {
+	Head   GitHead `json:"head"`
+	Branch string  `json:"branch,omitempty"`
+}
+
+// GitHead is the commit a job was created for.
+type GitHead struct {
+	ID string `json:"id"`
+}
+
+// SourceFile is the coverage of a single source file. Coverage has an element
+// for each line of the file, which is the number of times the line was
+// executed, or nil if the line isn't relevant.
+type SourceFile struct {
+	Name         string `json:"name"`
+	SourceDigest string `json:"source_digest"`
+	Coverage     []*int `json:"coverage"`
+}
+
+// SourceFiles converts the coverage into Coveralls source files, sorted by
+// name. The name of each file is the path returned by remap, which should be
+// relative to the repository root. The source is read from the root directory
+// on fsys to compute its digest and number of lines. If the source doesn't
+// exist, the file has no digest, and its coverage ends at its last covered
+// line.
+func SourceFiles(cov *types.Coverage, fsys vfs.FileSystem, root string, remap func(string) string) (
+	[]SourceFile, error,
+) {
+	files := make([]SourceFile, 0, len(cov.Files))
+	for absPath, blocks := range cov.Files {
+		sf := SourceFile{Name: remap(absPath)}
+
+		var numLines int
+		src, err := vfs.ReadFile(fsys, path.Join(root, sf.Name))
+		switch {
+		case err == nil:
+			digest := md5.Sum(src) //nolint:gosec // See the import.
+			sf.SourceDigest = hex.EncodeToString(digest[:])
+			numLines = bytes.Count(src, []byte("\n"))
+			if len(src) > 0 && src[len(src)-1] != '\n' {
+				numLines++
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed reading source file: %w", err)
+		}
+
+		sf.Coverage = lineCoverage(blocks, numLines)
+		files = append(files, sf)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
+
+// lineCoverage returns the hit count of each line of a file with numLines
+// lines. A line spanned by several blocks takes the maximum hit count of the
+// blocks. The result is extended to the end line of the last block if needed.
+func lineCoverage(blocks map[types.FileBlock]*types.Stats, numLines int) []*int {
+	for block := range blocks {
+		numLines = max(numLines, block.End.Line)
+	}
+
+	lines := make([]*int, numLines)
+	for block, stats := range blocks {
+		for line := block.Start.Line; line <= block.End.Line; line++ {
+			if line < 1 {
+				continue
+			}
+			if hits := lines[line-1]; hits == nil || *hits < stats.HitCount {
+				lines[line-1] = new(int)
+				*lines[line-1] = stats.HitCount
+			}
+		}
+	}
+
+	return lines
+}
+
+// JobResponse is the response of the server to a posted job.
+type JobResponse struct {
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+// PostJob sends the job to the server.
+func (c *Client) PostJob(ctx context.Context, job Job) (*JobResponse, error) {
+	job.RepoToken = c.token
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding job: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("json_file", "coverage.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating job request: %w", err)
+	}
+	if _, err = part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed creating job request: %w", err)
+	}
+	if err = mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed creating job request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/jobs", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating job request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var resp JobResponse
+	if err = c.send(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed posting job: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ParallelDone notifies the server that all parallel jobs of the build with
+// the given number have been posted, so that their coverage can be merged.
+func (c *Client) ParallelDone(ctx context.Context, buildNum string) error {
+	data, err := json.Marshal(map[string]any{
+		"repo_token": c.token,
+		"payload":    map[string]string{"build_num": buildNum, "status": "done"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed encoding webhook payload: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("repo_token", c.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/webhook?"+query.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err = c.send(req, nil); err != nil {
+		return fmt.Errorf("failed closing parallel build: %w", err)
+	}
+
+	return nil
+}
+
+// send sends the request, and decodes the JSON response into out, if it's not
+// nil.
+func (c *Client) send(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp.StatusCode, body)
+	}
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+// APIError is an error response returned by the server.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+		apiErr.Message = resp.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Coveralls returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
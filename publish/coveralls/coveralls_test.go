@@ -0,0 +1,151 @@
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestSourceFiles(t *testing.T) {
+	t.Parallel()
+
+	fs := memoryfs.New()
+	require.NoError(t, fs.MkdirAll("/repo/pkg", 0o755))
+	require.NoError(t, vfs.WriteFile(fs, "/repo/pkg/file.go", []byte("a\nb\nc\nd\ne\nf"), 0o644))
+
+	cov := types.NewCoverage()
+	cov.Files["example.com/mod/pkg/file.go"] = map[types.FileBlock]*types.Stats{
+		{Start: types.FileLocation{Line: 2, Col: 1}, End: types.FileLocation{Line: 3, Col: 5}}: {
+			NumStatements: 2, HitCount: 1,
+		},
+		{Start: types.FileLocation{Line: 3, Col: 5}, End: types.FileLocation{Line: 4, Col: 2}}: {
+			NumStatements: 1, HitCount: 3,
+		},
+		{Start: types.FileLocation{Line: 6, Col: 1}, End: types.FileLocation{Line: 6, Col: 2}}: {
+			NumStatements: 1, HitCount: 0,
+		},
+	}
+	cov.Files["example.com/mod/missing.go"] = map[types.FileBlock]*types.Stats{
+		{Start: types.FileLocation{Line: 2, Col: 1}, End: types.FileLocation{Line: 2, Col: 5}}: {
+			NumStatements: 1, HitCount: 1,
+		},
+	}
+
+	got, err := SourceFiles(cov, fs, "/repo", func(p string) string {
+		return strings.TrimPrefix(p, "example.com/mod/")
+	})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "missing.go", "source_digest": "", "coverage": [null, 1]},
+		{
+			"name": "pkg/file.go",
+			"source_digest": "a442036cc83ed004d65b962c2e4ec7c8",
+			"coverage": [null, 1, 3, 3, null, 0]
+		}
+	]`, string(data))
+}
+
+func TestClientPostJob(t *testing.T) {
+	t.Parallel()
+
+	var job map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/jobs" {
+			http.NotFound(w, r)
+			return
+		}
+		file, _, err := r.FormFile("json_file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		_ = json.Unmarshal(data, &job)
+		_, _ = w.Write([]byte(`{"message": "Job #1.1", "url": "https://coveralls.test/jobs/1"}`))
+	}))
+	defer srv.Close()
+
+	hits := 1
+	client := NewClient(srv.URL+"/", "secret", srv.Client())
+	got, err := client.PostJob(context.Background(), Job{
+		ServiceName:   "github",
+		ServiceJobID:  "123",
+		ServiceNumber: "45",
+		Parallel:      true,
+		FlagName:      "unit",
+		Git:           &Git{Head: GitHead{ID: "abc123"}, Branch: "main"},
+		SourceFiles: []SourceFile{
+			{Name: "file.go", SourceDigest: "deadbeef", Coverage: []*int{nil, &hits}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &JobResponse{Message: "Job #1.1", URL: "https://coveralls.test/jobs/1"}, got)
+
+	data, err := json.Marshal(job)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"repo_token": "secret",
+		"service_name": "github",
+		"service_job_id": "123",
+		"service_number": "45",
+		"parallel": true,
+		"flag_name": "unit",
+		"git": {"head": {"id": "abc123"}, "branch": "main"},
+		"source_files": [{"name": "file.go", "source_digest": "deadbeef", "coverage": [null, 1]}]
+	}`, string(data))
+}
+
+func TestClientParallelDone(t *testing.T) {
+	t.Parallel()
+
+	var (
+		query string
+		body  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/webhook" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = w.Write([]byte(`{"done": true}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	require.NoError(t, client.ParallelDone(context.Background(), "45"))
+	assert.Equal(t, "repo_token=secret", query)
+	assert.JSONEq(t, `{"repo_token": "secret", "payload": {"build_num": "45", "status": "done"}}`, body)
+}
+
+func TestClientError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message": "Couldn't find a repository matching this job.", "error": true}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	_, err := client.PostJob(context.Background(), Job{})
+	require.EqualError(t, err, "failed posting job: Coveralls returned 422 Unprocessable Entity: "+
+		"Couldn't find a repository matching this job.")
+}
@@ -0,0 +1,144 @@
+// Package github publishes coverage reports to GitHub.
+package github
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"go.hackfix.me/fcov/internal/fsutil"
+)
+
+// Env is the interface to the process environment.
+type Env interface {
+	Get(string) string
+}
+
+// InActions returns true if the process is running in a GitHub Actions
+// workflow.
+func InActions(env Env) bool {
+	return env != nil && env.Get("GITHUB_ACTIONS") == "true"
+}
+
+// Actions writes to the files and streams that the GitHub Actions runner reads
+// workflow data from.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+type Actions struct {
+	fs     vfs.FileSystem
+	env    Env
+	stdout io.Writer
+}
+
+// NewActions returns a new Actions instance. Files are written to fs, at the
+// paths set in env, and workflow commands are written to stdout.
+func NewActions(fs vfs.FileSystem, env Env, stdout io.Writer) *Actions {
+	return &Actions{fs: fs, env: env, stdout: stdout}
+}
+
+// AppendSummary appends the Markdown text to the job summary. It does nothing
+// if GITHUB_STEP_SUMMARY is not set.
+func (a *Actions) AppendSummary(markdown string) error {
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+
+	return a.appendEnvFile("GITHUB_STEP_SUMMARY", markdown)
+}
+
+// Output is a step output parameter.
+type Output struct {
+	Name, Value string
+}
+
+// SetOutputs sets the step output parameters, so that they can be used by
+// later steps in the job. It does nothing if GITHUB_OUTPUT is not set.
+func (a *Actions) SetOutputs(outputs ...Output) error {
+	var sb strings.Builder
+	for _, out := range outputs {
+		if !strings.ContainsAny(out.Value, "\r\n") {
+			fmt.Fprintf(&sb, "%s=%s\n", out.Name, out.Value)
+			continue
+		}
+
+		// Multiline values must be enclosed by a delimiter that doesn't appear
+		// in the value.
+		delim, err := randomDelimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%s<<%s\n%s\n%s\n", out.Name, delim, out.Value, delim)
+	}
+
+	return a.appendEnvFile("GITHUB_OUTPUT", sb.String())
+}
+
+// Annotation is a message associated with a range of lines in a file, which is
+// shown in the workflow run summary and in the pull request diff.
+type Annotation struct {
+	// Level is one of "notice", "warning" or "error".
+	Level   string
+	File    string
+	Line    int
+	EndLine int
+	Title   string
+	Message string
+}
+
+// Annotate writes the workflow command that creates the annotation.
+func (a *Actions) Annotate(ann Annotation) error {
+	props := []string{"file=" + escapeProperty(ann.File)}
+	if ann.Line > 0 {
+		props = append(props, "line="+strconv.Itoa(ann.Line))
+	}
+	if ann.EndLine > 0 {
+		props = append(props, "endLine="+strconv.Itoa(ann.EndLine))
+	}
+	if ann.Title != "" {
+		props = append(props, "title="+escapeProperty(ann.Title))
+	}
+
+	_, err := fmt.Fprintf(a.stdout, "::%s %s::%s\n",
+		ann.Level, strings.Join(props, ","), escapeData(ann.Message))
+	if err != nil {
+		return fmt.Errorf("failed writing workflow command: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Actions) appendEnvFile(envVar, data string) error {
+	fpath := a.env.Get(envVar)
+	if fpath == "" {
+		return nil
+	}
+
+	if err := fsutil.AppendFile(a.fs, fpath, []byte(data), 0o644); err != nil {
+		return fmt.Errorf("failed writing to %s file: %w", envVar, err)
+	}
+
+	return nil
+}
+
+func randomDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed generating output delimiter: %w", err)
+	}
+
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C",
+	).Replace(s)
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/internal/testfs"
+)
+
+type mapEnv map[string]string
+
+func (e mapEnv) Get(key string) string { return e[key] }
+
+func TestActionsAppendSummary(t *testing.T) {
+	t.Parallel()
+
+	fs := testfs.New()
+	err := vfs.WriteFile(fs, "/summary.md", []byte("# Tests\n"), 0o644)
+	require.NoError(t, err)
+
+	a := NewActions(fs, mapEnv{"GITHUB_STEP_SUMMARY": "/summary.md"}, nil)
+	require.NoError(t, a.AppendSummary("# Coverage"))
+
+	data, err := vfs.ReadFile(fs, "/summary.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Tests\n# Coverage\n", string(data))
+
+	// No-op if the variable isn't set.
+	a = NewActions(fs, mapEnv{}, nil)
+	require.NoError(t, a.AppendSummary("# Coverage"))
+}
+
+func TestActionsSetOutputs(t *testing.T) {
+	t.Parallel()
+
+	fs := testfs.New()
+	a := NewActions(fs, mapEnv{"GITHUB_OUTPUT": "/output"}, nil)
+	err := a.SetOutputs(
+		Output{Name: "total-coverage", Value: "45.04"},
+		Output{Name: "report", Value: "line 1\nline 2"},
+	)
+	require.NoError(t, err)
+
+	data, err := vfs.ReadFile(fs, "/output")
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(
+		`^total-coverage=45\.04\n`+
+			`report<<(ghadelimiter_[0-9a-f]{32})\nline 1\nline 2\n(ghadelimiter_[0-9a-f]{32})\n$`),
+		string(data))
+}
+
+func TestActionsAnnotate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		ann    Annotation
+		expOut string
+	}{
+		{
+			name: "full",
+			ann: Annotation{
+				Level: "warning", File: "pkg/file.go", Line: 10, EndLine: 12,
+				Title: "Uncovered code", Message: "Lines 10-12 are not covered by tests.",
+			},
+			expOut: "::warning file=pkg/file.go,line=10,endLine=12,title=Uncovered code::" +
+				"Lines 10-12 are not covered by tests.\n",
+		},
+		{
+			name: "escaped",
+			ann: Annotation{
+				Level: "notice", File: "a,b:c.go", Message: "100%\ncovered",
+			},
+			expOut: "::notice file=a%2Cb%3Ac.go::100%25%0Acovered\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout bytes.Buffer
+			a := NewActions(testfs.New(), mapEnv{}, &stdout)
+			require.NoError(t, a.Annotate(tt.ann))
+			assert.Equal(t, tt.expOut, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// maxAnnotationsPerRequest is the maximum amount of annotations the Checks API
+// accepts in a single request.
+const maxAnnotationsPerRequest = 50
+
+// MaxCheckAnnotations is the maximum amount of annotations added to a check
+// run, which limits the amount of requests needed to create it.
+const MaxCheckAnnotations = 1000
+
+// Conclusion is the final result of a check run.
+type Conclusion string
+
+// Check run conclusions.
+const (
+	ConclusionSuccess Conclusion = "success"
+	ConclusionNeutral Conclusion = "neutral"
+	ConclusionFailure Conclusion = "failure"
+)
+
+// CheckRun is a check run created on a commit.
+type CheckRun struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// CheckRunOptions are the values of a completed check run.
+type CheckRunOptions struct {
+	Name       string
+	HeadSHA    string
+	Conclusion Conclusion
+	// Title and Summary are shown in the check run page. Summary supports
+	// Markdown.
+	Title       string
+	Summary     string
+	Annotations []CheckAnnotation
+}
+
+// CheckAnnotation is a message associated with a range of lines in a file,
+// which is shown in the "Files changed" tab of pull requests.
+type CheckAnnotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Level is one of "notice", "warning" or "failure".
+	Level   string `json:"annotation_level"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+type checkRunOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+type checkRunRequest struct {
+	Name       string         `json:"name,omitempty"`
+	HeadSHA    string         `json:"head_sha,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Conclusion Conclusion     `json:"conclusion,omitempty"`
+	Output     checkRunOutput `json:"output"`
+}
+
+// CreateCheckRun creates a completed check run in repo (e.g. "hackfixme/fcov").
+// Since the API limits the amount of annotations per request, any annotations
+// beyond the first batch are added by updating the check run. Only the first
+// MaxCheckAnnotations annotations are added.
+func (c *Client) CreateCheckRun(ctx context.Context, repo string, opts CheckRunOptions) (*CheckRun, error) {
+	annotations := opts.Annotations
+	if len(annotations) > MaxCheckAnnotations {
+		annotations = annotations[:MaxCheckAnnotations]
+	}
+	batches := batchAnnotations(annotations)
+
+	req := checkRunRequest{
+		Name:       opts.Name,
+		HeadSHA:    opts.HeadSHA,
+		Status:     "completed",
+		Conclusion: opts.Conclusion,
+		Output: checkRunOutput{
+			Title: opts.Title, Summary: opts.Summary, Annotations: batches[0],
+		},
+	}
+	run := &CheckRun{}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", repo), req, run); err != nil {
+		return nil, fmt.Errorf("failed creating check run: %w", err)
+	}
+
+	for i, batch := range batches[1:] {
+		req = checkRunRequest{Output: checkRunOutput{
+			Title: opts.Title, Summary: opts.Summary, Annotations: batch,
+		}}
+		err := c.do(ctx, http.MethodPatch,
+			fmt.Sprintf("/repos/%s/check-runs/%d", repo, run.ID), req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed adding annotations batch %d to check run %d: %w",
+				i+2, run.ID, err)
+		}
+	}
+
+	return run, nil
+}
+
+// batchAnnotations splits the annotations into batches of at most
+// maxAnnotationsPerRequest. It always returns at least one batch.
+func batchAnnotations(annotations []CheckAnnotation) [][]CheckAnnotation {
+	batches := [][]CheckAnnotation{nil}
+	for i, ann := range annotations {
+		if i > 0 && i%maxAnnotationsPerRequest == 0 {
+			batches = append(batches, nil)
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], ann)
+	}
+
+	return batches
+}
+
+// EventHeadSHA returns the head commit SHA of the pull request that triggered
+// the GitHub Actions workflow, read from the event payload file. It returns an
+// empty string if the workflow wasn't triggered by a pull request. This is
+// needed since the commit being built for pull requests is a merge commit,
+// and check runs must be created on the head commit to be shown in the pull
+// request.
+func EventHeadSHA(fs vfs.FileSystem, env Env) (string, error) {
+	fpath := env.Get("GITHUB_EVENT_PATH")
+	if fpath == "" {
+		return "", nil
+	}
+
+	data, err := vfs.ReadFile(fs, fpath)
+	if err != nil {
+		return "", fmt.Errorf("failed reading event payload: %w", err)
+	}
+
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if err = json.Unmarshal(data, &event); err != nil {
+		return "", fmt.Errorf("failed decoding event payload: %w", err)
+	}
+
+	return event.PullRequest.Head.SHA, nil
+}
@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCreateCheckRun(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		numAnnotations int
+		expBatches     []int
+	}{
+		{name: "no_annotations", numAnnotations: 0, expBatches: []int{0}},
+		{name: "single_batch", numAnnotations: 50, expBatches: []int{50}},
+		{name: "multiple_batches", numAnnotations: 120, expBatches: []int{50, 50, 20}},
+		{
+			name: "max_annotations", numAnnotations: MaxCheckAnnotations + 30,
+			expBatches: slices.Repeat([]int{50}, MaxCheckAnnotations/50),
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				requests []string
+				batches  []int
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				var req checkRunRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				batches = append(batches, len(req.Output.Annotations))
+				assert.Equal(t, "Total coverage: 45.04%", req.Output.Title)
+
+				if r.Method == http.MethodPost {
+					assert.Equal(t, "fcov", req.Name)
+					assert.Equal(t, "0123456789abcdef", req.HeadSHA)
+					assert.Equal(t, "completed", req.Status)
+					assert.Equal(t, ConclusionFailure, req.Conclusion)
+					w.WriteHeader(http.StatusCreated)
+				}
+				_, _ = w.Write([]byte(`{"id": 7, "html_url": "https://github.com/org/repo/runs/7"}`))
+			}))
+			defer srv.Close()
+
+			annotations := make([]CheckAnnotation, tt.numAnnotations)
+			for i := range annotations {
+				annotations[i] = CheckAnnotation{
+					Path: "file.go", StartLine: i + 1, EndLine: i + 1, Level: "warning",
+					Message: fmt.Sprintf("Line %d is not covered by tests.", i+1),
+				}
+			}
+
+			client := NewClient(srv.URL, "secret", srv.Client())
+			run, err := client.CreateCheckRun(context.Background(), "org/repo", CheckRunOptions{
+				Name: "fcov", HeadSHA: "0123456789abcdef", Conclusion: ConclusionFailure,
+				Title: "Total coverage: 45.04%", Summary: "report", Annotations: annotations,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, &CheckRun{ID: 7, HTMLURL: "https://github.com/org/repo/runs/7"}, run)
+			assert.Equal(t, tt.expBatches, batches)
+
+			expRequests := []string{"POST /repos/org/repo/check-runs"}
+			for range tt.expBatches[1:] {
+				expRequests = append(expRequests, "PATCH /repos/org/repo/check-runs/7")
+			}
+			assert.Equal(t, expRequests, requests)
+		})
+	}
+}
+
+func TestEventHeadSHA(t *testing.T) {
+	t.Parallel()
+
+	fs := memoryfs.New()
+	err := vfs.WriteFile(fs, "/pr_event.json",
+		[]byte(`{"number": 42, "pull_request": {"head": {"sha": "fedcba9876543210"}}}`), 0o644)
+	require.NoError(t, err)
+	err = vfs.WriteFile(fs, "/push_event.json", []byte(`{"after": "0123456789abcdef"}`), 0o644)
+	require.NoError(t, err)
+
+	sha, err := EventHeadSHA(fs, mapEnv{"GITHUB_EVENT_PATH": "/pr_event.json"})
+	require.NoError(t, err)
+	assert.Equal(t, "fedcba9876543210", sha)
+
+	sha, err = EventHeadSHA(fs, mapEnv{"GITHUB_EVENT_PATH": "/push_event.json"})
+	require.NoError(t, err)
+	assert.Equal(t, "", sha)
+
+	sha, err = EventHeadSHA(fs, mapEnv{})
+	require.NoError(t, err)
+	assert.Equal(t, "", sha)
+
+	_, err = EventHeadSHA(fs, mapEnv{"GITHUB_EVENT_PATH": "/missing.json"})
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// commentsPerPage is the maximum amount of comments returned by the API in
+// a single page.
+const commentsPerPage = 100
+
+// Comment is a pull request comment.
+type Comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// UpsertComment updates the comment on the pull request pr in repo (e.g.
+// "hackfixme/fcov") that contains marker, or creates a new one if none is
+// found. The marker is prepended to body. It returns the comment and whether
+// it was created.
+func (c *Client) UpsertComment(
+	ctx context.Context, repo string, pr int, marker, body string,
+) (*Comment, bool, error) {
+	existing, err := c.findComment(ctx, repo, pr, marker)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed finding existing comment: %w", err)
+	}
+
+	req := map[string]string{"body": marker + "\n" + body}
+	comment := &Comment{}
+	if existing == nil {
+		err = c.do(ctx, http.MethodPost,
+			fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr), req, comment)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed creating comment: %w", err)
+		}
+		return comment, true, nil
+	}
+
+	err = c.do(ctx, http.MethodPatch,
+		fmt.Sprintf("/repos/%s/issues/comments/%d", repo, existing.ID), req, comment)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed updating comment %d: %w", existing.ID, err)
+	}
+
+	return comment, false, nil
+}
+
+// findComment returns the first comment on the pull request that contains
+// marker, or nil if none is found.
+func (c *Client) findComment(ctx context.Context, repo string, pr int, marker string) (*Comment, error) {
+	for page := 1; ; page++ {
+		var comments []Comment
+		err := c.do(ctx, http.MethodGet,
+			fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d",
+				repo, pr, commentsPerPage, page), nil, &comments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, marker) {
+				return &comment, nil
+			}
+		}
+
+		if len(comments) < commentsPerPage {
+			return nil, nil //nolint:nilnil // Not finding a comment is not an error.
+		}
+	}
+}
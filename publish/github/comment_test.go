@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/publish"
+)
+
+// fakeIssueComments is a stand-in for the issue comments endpoints of the
+// GitHub API.
+type fakeIssueComments struct {
+	t        *testing.T
+	comments []Comment
+	requests []string
+}
+
+func (f *fakeIssueComments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	assert.Equal(f.t, "Bearer secret", r.Header.Get("Authorization"))
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/org/repo/issues/42/comments":
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		start := min((page-1)*perPage, len(f.comments))
+		end := min(start+perPage, len(f.comments))
+		_ = json.NewEncoder(w).Encode(f.comments[start:end])
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/org/repo/issues/42/comments":
+		var req map[string]string
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		c := Comment{ID: int64(len(f.comments) + 1), Body: req["body"]}
+		c.HTMLURL = fmt.Sprintf("https://github.com/org/repo/pull/42#issuecomment-%d", c.ID)
+		f.comments = append(f.comments, c)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(c)
+	case r.Method == http.MethodPatch:
+		var id int64
+		_, err := fmt.Sscanf(r.URL.Path, "/repos/org/repo/issues/comments/%d", &id)
+		require.NoError(f.t, err)
+		var req map[string]string
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.comments[id-1].Body = req["body"]
+		_ = json.NewEncoder(w).Encode(f.comments[id-1])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+func TestClientUpsertComment(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeIssueComments{t: t}
+	// Fill more than one page of unrelated comments.
+	for i := range commentsPerPage + 5 {
+		fake.comments = append(fake.comments, Comment{ID: int64(i + 1), Body: "LGTM"})
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	ctx := context.Background()
+	marker := publish.Marker("default")
+
+	comment, created, err := client.UpsertComment(ctx, "org/repo", 42, marker, "Coverage: 50%")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, int64(commentsPerPage+6), comment.ID)
+	assert.Equal(t, "<!-- fcov:default -->\nCoverage: 50%", comment.Body)
+
+	// A comment with a different marker is created separately.
+	_, created, err = client.UpsertComment(ctx, "org/repo", 42, publish.Marker("other"), "Coverage: 10%")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	fake.requests = nil
+	comment, created, err = client.UpsertComment(ctx, "org/repo", 42, marker, "Coverage: 75%")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, int64(commentsPerPage+6), comment.ID)
+	assert.Equal(t, "<!-- fcov:default -->\nCoverage: 75%", fake.comments[commentsPerPage+5].Body)
+	assert.Len(t, fake.comments, commentsPerPage+7)
+	assert.Equal(t, []string{
+		"GET /repos/org/repo/issues/42/comments",
+		"GET /repos/org/repo/issues/42/comments",
+		fmt.Sprintf("PATCH /repos/org/repo/issues/comments/%d", commentsPerPage+6),
+	}, fake.requests)
+}
+
+func TestClientUpsertCommentError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(&fakeIssueComments{t: t})
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	_, _, err := client.UpsertComment(context.Background(), "org/other", 1, publish.Marker("default"), "")
+	require.Error(t, err)
+	assert.EqualError(t, err, "failed finding existing comment: GitHub API returned 404 Not Found: Not Found")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
@@ -0,0 +1,54 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.hackfix.me/fcov/diff"
+)
+
+// Pagination limits of the pull request files API, which returns at most
+// 3000 files.
+const (
+	filesPerPage = 100
+	maxFilePages = 30
+)
+
+// PullRequestChanges returns the files changed by the pull request pr in repo
+// (e.g. "hackfixme/fcov"), and the lines added or modified in them. Removed
+// files are not included. Files whose patch is not returned by the API, e.g.
+// because it's too large, are included without lines.
+func (c *Client) PullRequestChanges(ctx context.Context, repo string, pr int) (diff.Changes, error) {
+	changes := make(diff.Changes)
+	for page := 1; page <= maxFilePages; page++ {
+		var files []struct {
+			Filename string `json:"filename"`
+			Status   string `json:"status"`
+			Patch    string `json:"patch"`
+		}
+		err := c.do(ctx, http.MethodGet,
+			fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=%d&page=%d",
+				repo, pr, filesPerPage, page), nil, &files)
+		if err != nil {
+			return nil, fmt.Errorf("failed listing files of pull request %d: %w", pr, err)
+		}
+
+		for _, file := range files {
+			if file.Status == "removed" {
+				continue
+			}
+			ranges, err := diff.ParseHunks(file.Patch)
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing patch of %s: %w", file.Filename, err)
+			}
+			changes[file.Filename] = ranges
+		}
+
+		if len(files) < filesPerPage {
+			break
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/diff"
+)
+
+func TestClientPullRequestChanges(t *testing.T) {
+	t.Parallel()
+
+	// The first page is full, so that the second page is requested.
+	firstPage := make([]map[string]string, filesPerPage)
+	for i := range firstPage {
+		firstPage[i] = map[string]string{
+			"filename": fmt.Sprintf("docs/file%d.md", i), "status": "modified",
+			"patch": "@@ -1 +1 @@\n-a\n+b",
+		}
+	}
+	secondPage := []map[string]string{
+		{"filename": "pkg/file.go", "status": "modified", "patch": "@@ -10,2 +10,3 @@\n a\n+b\n+c\n-d"},
+		{"filename": "pkg/large.go", "status": "added"},
+		{"filename": "pkg/old.go", "status": "removed", "patch": "@@ -1 +0,0 @@\n-a"},
+	}
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.String())
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		page := firstPage
+		if r.URL.Query().Get("page") == "2" {
+			page = secondPage
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "secret", srv.Client())
+	changes, err := client.PullRequestChanges(context.Background(), "org/repo", 42)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /repos/org/repo/pulls/42/files?per_page=100&page=1",
+		"GET /repos/org/repo/pulls/42/files?per_page=100&page=2",
+	}, requests)
+	assert.Len(t, changes, filesPerPage+2)
+	assert.Equal(t, []diff.Range{{Start: 1, End: 1}}, changes["docs/file0.md"])
+	assert.Equal(t, []diff.Range{{Start: 11, End: 12}}, changes["pkg/file.go"])
+	assert.True(t, changes.Changed("pkg/large.go"))
+	assert.False(t, changes.Changed("pkg/old.go"))
+}
@@ -0,0 +1,146 @@
+// Package gitlab publishes coverage reports to GitLab.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultAPIURL is the base URL of the GitLab.com REST API.
+const DefaultAPIURL = "https://gitlab.com/api/v4"
+
+// TokenType is the type of token used to authenticate with the API.
+type TokenType int
+
+// Token types.
+const (
+	// PrivateToken is a personal, project or group access token.
+	PrivateToken TokenType = iota
+	// JobToken is the CI_JOB_TOKEN of a GitLab CI job.
+	JobToken
+)
+
+// Client is a minimal client of the GitLab REST API.
+type Client struct {
+	baseURL   string
+	token     string
+	tokenType TokenType
+	http      *http.Client
+}
+
+// NewClient returns a new Client that sends requests to the API at baseURL,
+// authenticated with a token of the given type. If baseURL is empty,
+// DefaultAPIURL is used. For self-hosted instances, baseURL is usually
+// "https://<host>/api/v4". If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL, token string, tokenType TokenType, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		token:     token,
+		tokenType: tokenType,
+		http:      httpClient,
+	}
+}
+
+// APIError is an error response returned by the GitLab API.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("GitLab API returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+// do sends a request with the JSON encoded body to the API path, and decodes
+// the JSON response into out, if it's not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed encoding request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		header := "PRIVATE-TOKEN"
+		if c.tokenType == JobToken {
+			header = "JOB-TOKEN"
+		}
+		req.Header.Set(header, c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+// newAPIError returns the error of a failed response. GitLab returns the error
+// message either in the "message" or the "error" field, and the message can be
+// a string or an object.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	// The message is optional, so ignore decoding errors.
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return apiErr
+	}
+
+	var msg string
+	switch {
+	case json.Unmarshal(body.Message, &msg) == nil:
+		apiErr.Message = msg
+	case len(body.Message) > 0:
+		apiErr.Message = string(body.Message)
+	default:
+		apiErr.Message = body.Error
+	}
+
+	return apiErr
+}
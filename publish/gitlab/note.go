@@ -0,0 +1,74 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// notesPerPage is the maximum amount of notes returned by the API in a single
+// page.
+const notesPerPage = 100
+
+// Note is a merge request note, i.e. a comment.
+type Note struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// UpsertNote updates the note on the merge request mr in project that contains
+// marker, or creates a new one if none is found. The project can either be its
+// numeric ID or its path, e.g. "hackfixme/fcov". The marker is prepended to
+// body. It returns the note and whether it was created.
+func (c *Client) UpsertNote(
+	ctx context.Context, project string, mr int, marker, body string,
+) (*Note, bool, error) {
+	notesPath := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", url.PathEscape(project), mr)
+
+	existing, err := c.findNote(ctx, notesPath, marker)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed finding existing note: %w", err)
+	}
+
+	req := map[string]string{"body": marker + "\n" + body}
+	note := &Note{}
+	if existing == nil {
+		if err = c.do(ctx, http.MethodPost, notesPath, req, note); err != nil {
+			return nil, false, fmt.Errorf("failed creating note: %w", err)
+		}
+		return note, true, nil
+	}
+
+	err = c.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", notesPath, existing.ID), req, note)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed updating note %d: %w", existing.ID, err)
+	}
+
+	return note, false, nil
+}
+
+// findNote returns the first note that contains marker, or nil if none is
+// found.
+func (c *Client) findNote(ctx context.Context, notesPath, marker string) (*Note, error) {
+	for page := 1; ; page++ {
+		var notes []Note
+		err := c.do(ctx, http.MethodGet,
+			fmt.Sprintf("%s?sort=asc&per_page=%d&page=%d", notesPath, notesPerPage, page),
+			nil, &notes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return &note, nil
+			}
+		}
+
+		if len(notes) < notesPerPage {
+			return nil, nil //nolint:nilnil // Not finding a note is not an error.
+		}
+	}
+}
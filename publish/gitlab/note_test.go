@@ -0,0 +1,116 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/publish"
+)
+
+func TestClientUpsertNote(t *testing.T) {
+	t.Parallel()
+
+	const notesPath = "/projects/group%2Fproject/merge_requests/7/notes"
+
+	notes := []Note{{ID: 1, Body: "LGTM"}}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		assert.Equal(t, "job-secret", r.Header.Get("JOB-TOKEN"))
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(notes)
+		case http.MethodPost:
+			var req map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			notes = append(notes, Note{ID: int64(len(notes) + 1), Body: req["body"]})
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(notes[len(notes)-1])
+		case http.MethodPut:
+			var req map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			id, err := strconv.Atoi(r.URL.Path[len(r.URL.Path)-1:])
+			assert.NoError(t, err)
+			notes[id-1].Body = req["body"]
+			_ = json.NewEncoder(w).Encode(notes[id-1])
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "job-secret", JobToken, srv.Client())
+	ctx := context.Background()
+	marker := publish.Marker("default")
+
+	note, created, err := client.UpsertNote(ctx, "group/project", 7, marker, "Coverage: 50%")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, &Note{ID: 2, Body: "<!-- fcov:default -->\nCoverage: 50%"}, note)
+
+	note, created, err = client.UpsertNote(ctx, "group/project", 7, marker, "Coverage: 75%")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, &Note{ID: 2, Body: "<!-- fcov:default -->\nCoverage: 75%"}, note)
+	assert.Len(t, notes, 2)
+
+	assert.Equal(t, []string{
+		"GET " + notesPath, "POST " + notesPath,
+		"GET " + notesPath, "PUT " + notesPath + "/2",
+	}, requests)
+}
+
+func TestClientUpsertNoteError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		body   string
+		expErr string
+	}{
+		{
+			name:   "message_string",
+			body:   `{"message": "401 Unauthorized"}`,
+			expErr: "GitLab API returned 401 Unauthorized: 401 Unauthorized",
+		},
+		{
+			name:   "message_object",
+			body:   `{"message": {"body": ["is too long"]}}`,
+			expErr: `GitLab API returned 401 Unauthorized: {"body": ["is too long"]}`,
+		},
+		{
+			name:   "error",
+			body:   `{"error": "invalid_token"}`,
+			expErr: "GitLab API returned 401 Unauthorized: invalid_token",
+		},
+		{
+			name:   "empty",
+			expErr: "GitLab API returned 401 Unauthorized",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := NewClient(srv.URL, "secret", PrivateToken, srv.Client())
+			_, _, err := client.UpsertNote(context.Background(), "1", 7, publish.Marker("default"), "")
+			assert.EqualError(t, err, "failed finding existing note: "+tt.expErr)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Package otlp exports metrics to an OpenTelemetry collector over OTLP/HTTP,
+// using the JSON encoding of the protocol.
+// See https://opentelemetry.io/docs/specs/otlp/#otlphttp
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the default base URL of an OTLP/HTTP collector.
+const DefaultEndpoint = "http://localhost:4318"
+
+// Client exports metrics to an OTLP/HTTP collector.
+type Client struct {
+	endpoint string
+	headers  map[string]string
+	http     *http.Client
+}
+
+// NewClient returns a new Client that exports to the collector at the base URL
+// endpoint, sending the given headers with each request, e.g. for
+// authentication. If endpoint is empty, DefaultEndpoint is used. If httpClient
+// is nil, http.DefaultClient is used.
+func NewClient(endpoint string, headers map[string]string, httpClient *http.Client) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		http:     httpClient,
+	}
+}
+
+// Metrics is a set of gauges measured at the same time, and the attributes of
+// the resource they were measured for.
+type Metrics struct {
+	Resource map[string]string
+	// ScopeName and ScopeVersion identify the instrumentation scope, e.g. "fcov".
+	ScopeName, ScopeVersion string
+	Time                    time.Time
+	Gauges                  []Gauge
+}
+
+// Gauge is a metric with the last measured value of each data point.
+type Gauge struct {
+	Name, Description, Unit string
+	// Int encodes the values of the data points as integers.
+	Int        bool
+	DataPoints []DataPoint
+}
+
+// DataPoint is the value of a gauge for a set of attributes.
+type DataPoint struct {
+	Attributes map[string]string
+	Value      float64
+}
+
+// Export sends the metrics to the collector. It returns an error if the
+// collector rejects any data point.
+func (c *Client) Export(ctx context.Context, m Metrics) error {
+	data, err := json.Marshal(encodeMetrics(m))
+	if err != nil {
+		return fmt.Errorf("failed encoding metrics: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/v1/metrics", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed creating export request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed exporting metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed exporting metrics: %w", newAPIError(resp.StatusCode, body))
+	}
+
+	var result struct {
+		PartialSuccess struct {
+			RejectedDataPoints json.Number `json:"rejectedDataPoints"`
+			ErrorMessage       string      `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &result) != nil {
+		return nil
+	}
+	if rejected := result.PartialSuccess.RejectedDataPoints; rejected != "" && rejected != "0" {
+		msg := fmt.Sprintf("collector rejected %s data points", rejected)
+		if result.PartialSuccess.ErrorMessage != "" {
+			msg += ": " + result.PartialSuccess.ErrorMessage
+		}
+		return fmt.Errorf("failed exporting metrics: %s", msg)
+	}
+
+	return nil
+}
+
+// The JSON encoding of an ExportMetricsServiceRequest. 64-bit integers are
+// encoded as strings.
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+type (
+	exportRequest struct {
+		ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+	}
+	resourceMetrics struct {
+		Resource     resource       `json:"resource"`
+		ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeMetrics struct {
+		Scope   scope    `json:"scope"`
+		Metrics []metric `json:"metrics"`
+	}
+	scope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	metric struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Unit        string `json:"unit,omitempty"`
+		Gauge       gauge  `json:"gauge"`
+	}
+	gauge struct {
+		DataPoints []numberDataPoint `json:"dataPoints"`
+	}
+	numberDataPoint struct {
+		Attributes   []keyValue `json:"attributes,omitempty"`
+		TimeUnixNano string     `json:"timeUnixNano"`
+		AsDouble     *float64   `json:"asDouble,omitempty"`
+		AsInt        string     `json:"asInt,omitempty"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+func encodeMetrics(m Metrics) exportRequest {
+	ts := strconv.FormatInt(m.Time.UnixNano(), 10)
+	metrics := make([]metric, 0, len(m.Gauges))
+	for _, g := range m.Gauges {
+		dps := make([]numberDataPoint, 0, len(g.DataPoints))
+		for _, dp := range g.DataPoints {
+			ndp := numberDataPoint{Attributes: encodeAttributes(dp.Attributes), TimeUnixNano: ts}
+			if g.Int {
+				ndp.AsInt = strconv.FormatInt(int64(dp.Value), 10)
+			} else {
+				ndp.AsDouble = &dp.Value
+			}
+			dps = append(dps, ndp)
+		}
+		metrics = append(metrics, metric{
+			Name: g.Name, Description: g.Description, Unit: g.Unit,
+			Gauge: gauge{DataPoints: dps},
+		})
+	}
+
+	return exportRequest{ResourceMetrics: []resourceMetrics{{
+		Resource: resource{Attributes: encodeAttributes(m.Resource)},
+		ScopeMetrics: []scopeMetrics{{
+			Scope:   scope{Name: m.ScopeName, Version: m.ScopeVersion},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// encodeAttributes returns the attributes sorted by key, omitting the ones
+// with empty values.
+func encodeAttributes(attrs map[string]string) []keyValue {
+	kvs := make([]keyValue, 0, len(attrs))
+	for key, value := range attrs {
+		if value == "" {
+			continue
+		}
+		kvs = append(kvs, keyValue{Key: key, Value: anyValue{StringValue: value}})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+	return kvs
+}
+
+// APIError is an error response returned by the collector.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	var status struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &status); err == nil && status.Message != "" {
+		apiErr.Message = status.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("OTLP collector returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
@@ -0,0 +1,138 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientExport(t *testing.T) {
+	t.Parallel()
+
+	var (
+		header http.Header
+		body   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		header = r.Header
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/", map[string]string{"Authorization": "Bearer secret"}, srv.Client())
+	err := client.Export(context.Background(), Metrics{
+		Resource:     map[string]string{"service.name": "fcov", "vcs.ref.head.name": "main", "empty": ""},
+		ScopeName:    "fcov",
+		ScopeVersion: "v1.2.3",
+		Time:         time.Unix(1735787045, 0),
+		Gauges: []Gauge{
+			{
+				Name: "fcov.coverage.ratio", Unit: "1", Description: "Ratio of statements covered by tests.",
+				DataPoints: []DataPoint{
+					{Attributes: map[string]string{"scope": "total"}, Value: 0.75},
+					{Attributes: map[string]string{"scope": "package", "package": "pkg1"}, Value: 0},
+				},
+			},
+			{
+				Name: "fcov.statements", Unit: "{statement}", Int: true,
+				DataPoints: []DataPoint{{Attributes: map[string]string{"scope": "total"}, Value: 4}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.JSONEq(t, `{"resourceMetrics": [{
+		"resource": {"attributes": [
+			{"key": "service.name", "value": {"stringValue": "fcov"}},
+			{"key": "vcs.ref.head.name", "value": {"stringValue": "main"}}
+		]},
+		"scopeMetrics": [{
+			"scope": {"name": "fcov", "version": "v1.2.3"},
+			"metrics": [
+				{
+					"name": "fcov.coverage.ratio",
+					"description": "Ratio of statements covered by tests.",
+					"unit": "1",
+					"gauge": {"dataPoints": [
+						{
+							"attributes": [{"key": "scope", "value": {"stringValue": "total"}}],
+							"timeUnixNano": "1735787045000000000",
+							"asDouble": 0.75
+						},
+						{
+							"attributes": [
+								{"key": "package", "value": {"stringValue": "pkg1"}},
+								{"key": "scope", "value": {"stringValue": "package"}}
+							],
+							"timeUnixNano": "1735787045000000000",
+							"asDouble": 0
+						}
+					]}
+				},
+				{
+					"name": "fcov.statements",
+					"unit": "{statement}",
+					"gauge": {"dataPoints": [{
+						"attributes": [{"key": "scope", "value": {"stringValue": "total"}}],
+						"timeUnixNano": "1735787045000000000",
+						"asInt": "4"
+					}]}
+				}
+			]
+		}]
+	}]}`, body)
+}
+
+func TestClientExportError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "status",
+			status:  http.StatusBadRequest,
+			body:    `{"code": 3, "message": "invalid metric"}`,
+			wantErr: "failed exporting metrics: OTLP collector returned 400 Bad Request: invalid metric",
+		},
+		{
+			name:    "partial_success",
+			status:  http.StatusOK,
+			body:    `{"partialSuccess": {"rejectedDataPoints": "2", "errorMessage": "too old"}}`,
+			wantErr: "failed exporting metrics: collector rejected 2 data points: too old",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := NewClient(srv.URL, nil, srv.Client()).Export(context.Background(), Metrics{})
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
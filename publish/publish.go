@@ -0,0 +1,12 @@
+// Package publish contains the integrations that publish coverage reports to
+// code hosts and other external services. Each service is implemented in its
+// own subpackage.
+package publish
+
+import "fmt"
+
+// Marker returns the hidden HTML comment used to find the comment previously
+// created for the report with the given ID.
+func Marker(id string) string {
+	return fmt.Sprintf("<!-- fcov:%s -->", id)
+}
@@ -0,0 +1,81 @@
+// Package webhook sends JSON payloads to generic webhooks, like the incoming
+// webhooks of Slack, Microsoft Teams or Mattermost.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidPayload is returned when the payload isn't valid JSON.
+var ErrInvalidPayload = errors.New("payload is not valid JSON")
+
+// Client sends payloads to webhooks.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a new Client. If httpClient is nil, http.DefaultClient is
+// used.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{http: httpClient}
+}
+
+// Send posts the JSON payload to the webhook URL, with the given extra
+// headers. It returns ErrInvalidPayload if the payload isn't valid JSON.
+func (c *Client) Send(ctx context.Context, url string, headers map[string]string, payload []byte) error {
+	if !json.Valid(payload) {
+		return ErrInvalidPayload
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed sending webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+
+	return nil
+}
+
+// APIError is an error response returned by the webhook.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface for APIError.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("webhook returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
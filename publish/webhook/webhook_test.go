@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSend(t *testing.T) {
+	t.Parallel()
+
+	var (
+		header http.Header
+		body   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/hook" {
+			http.NotFound(w, r)
+			return
+		}
+		header = r.Header
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.Client())
+	err := client.Send(context.Background(), srv.URL+"/hook",
+		map[string]string{"X-Token": "secret"}, []byte(`{"text": "Coverage: 45.04%"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "secret", header.Get("X-Token"))
+	assert.Equal(t, `{"text": "Coverage: 45.04%"}`, body)
+}
+
+func TestClientSendError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.Client())
+	err := client.Send(context.Background(), srv.URL, nil, []byte(`{}`))
+	require.EqualError(t, err, "webhook returned 400 Bad Request: invalid_payload")
+
+	err = client.Send(context.Background(), srv.URL, nil, []byte(`{"text": }`))
+	require.ErrorIs(t, err, ErrInvalidPayload)
+}
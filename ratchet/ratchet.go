@@ -0,0 +1,163 @@
+// Package ratchet records the minimum coverage of each package in a ratchet
+// file, which is only ever raised, so that coverage can't decrease.
+package ratchet
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// header is written at the top of the ratchet file.
+const header = `# Minimum coverage percentage of each package, checked by 'fcov ratchet'.
+# Minimums are raised with 'fcov ratchet --update', and are never lowered.
+`
+
+// Minimums maps package paths to their minimum coverage percentage.
+type Minimums map[string]float64
+
+// Violation is a package whose coverage is below its minimum.
+type Violation struct {
+	Package  string
+	Coverage float64
+	Minimum  float64
+}
+
+// Result is the outcome of comparing the coverage of packages to their
+// minimums.
+type Result struct {
+	// Violations are the packages whose coverage is below their minimum.
+	Violations []Violation
+	// Raised are the packages whose coverage is above their minimum.
+	Raised []string
+	// Added are the packages that don't have a minimum.
+	Added []string
+	// Missing are the packages that have a minimum, but no coverage.
+	Missing []string
+}
+
+// Check compares the coverage percentage of each package to its minimum. All
+// slices of the result are sorted by package path.
+func (m Minimums) Check(coverage map[string]float64) Result {
+	var res Result
+	for _, pkg := range sortedKeys(coverage) {
+		pct := Truncate(coverage[pkg])
+		minPct, ok := m[pkg]
+		switch {
+		case !ok:
+			res.Added = append(res.Added, pkg)
+		case pct < minPct:
+			res.Violations = append(res.Violations, Violation{Package: pkg, Coverage: pct, Minimum: minPct})
+		case pct > minPct:
+			res.Raised = append(res.Raised, pkg)
+		}
+	}
+	for _, pkg := range sortedKeys(m) {
+		if _, ok := coverage[pkg]; !ok {
+			res.Missing = append(res.Missing, pkg)
+		}
+	}
+
+	return res
+}
+
+// Update returns a copy of the minimums raised to the coverage percentage of
+// each package, and with minimums added for packages that don't have one.
+// Minimums are never lowered. Packages without coverage are kept, unless prune
+// is true.
+func (m Minimums) Update(coverage map[string]float64, prune bool) Minimums {
+	updated := make(Minimums, len(m))
+	for pkg, minPct := range m {
+		if _, ok := coverage[pkg]; ok || !prune {
+			updated[pkg] = minPct
+		}
+	}
+	for pkg, pct := range coverage {
+		updated[pkg] = max(updated[pkg], Truncate(pct))
+	}
+
+	return updated
+}
+
+// Truncate truncates the coverage percentage pct to 2 decimal places, the
+// precision of the ratchet file. It's truncated instead of rounded, so that
+// the recorded minimum is never higher than the actual coverage.
+func Truncate(pct float64) float64 {
+	// The small offset accounts for floating point errors, e.g. of 0.29*100.
+	return math.Floor(pct*100+1e-6) / 100
+}
+
+// Load reads the minimums from the ratchet file at path. Each line of the file
+// contains a package path and its minimum coverage percentage, separated by
+// whitespace. Empty lines and lines starting with '#' are ignored. It returns
+// an error that wraps fs.ErrNotExist if the file doesn't exist.
+func Load(fsys vfs.FileSystem, path string) (Minimums, error) {
+	data, err := vfs.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ratchet file %s doesn't exist: %w", path, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading ratchet file: %w", err)
+	}
+
+	m := make(Minimums)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid ratchet entry on line %d: %s", line, text)
+		}
+		var pct float64
+		if pct, err = strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64); err != nil {
+			return nil, fmt.Errorf("invalid coverage percentage on line %d: %w", line, err)
+		}
+		m[fields[0]] = pct
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading ratchet file: %w", err)
+	}
+
+	return m, nil
+}
+
+// Save writes the minimums to the ratchet file at path, sorted by package
+// path, so that changes are easy to review.
+func Save(fsys vfs.FileSystem, path string, m Minimums) error {
+	var buf strings.Builder
+	buf.WriteString(header)
+	width := 0
+	for pkg := range m {
+		width = max(width, len(pkg))
+	}
+	for _, pkg := range sortedKeys(m) {
+		fmt.Fprintf(&buf, "%-*s  %6.2f\n", width, pkg, m[pkg])
+	}
+
+	if err := vfs.WriteFile(fsys, path, []byte(buf.String()), 0o644); err != nil {
+		return fmt.Errorf("failed writing ratchet file: %w", err)
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
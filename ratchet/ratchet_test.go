@@ -0,0 +1,87 @@
+package ratchet
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimumsCheck(t *testing.T) {
+	t.Parallel()
+
+	m := Minimums{"pkg1": 50, "pkg2": 72.41, "pkg3": 80, "pkg4": 10}
+	res := m.Check(map[string]float64{
+		"pkg1": 49.999, "pkg2": 72.41379310344827, "pkg3": 90, "pkg5": 30,
+	})
+
+	assert.Equal(t, Result{
+		Violations: []Violation{{Package: "pkg1", Coverage: 49.99, Minimum: 50}},
+		Raised:     []string{"pkg3"},
+		Added:      []string{"pkg5"},
+		Missing:    []string{"pkg4"},
+	}, res)
+}
+
+func TestMinimumsUpdate(t *testing.T) {
+	t.Parallel()
+
+	m := Minimums{"pkg1": 50, "pkg2": 70, "pkg3": 80}
+	coverage := map[string]float64{"pkg1": 40, "pkg2": 75.555, "pkg4": 30}
+
+	testCases := []struct {
+		name  string
+		prune bool
+		exp   Minimums
+	}{
+		{name: "keep", exp: Minimums{"pkg1": 50, "pkg2": 75.55, "pkg3": 80, "pkg4": 30}},
+		{name: "prune", prune: true, exp: Minimums{"pkg1": 50, "pkg2": 75.55, "pkg4": 30}},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.exp, m.Update(coverage, tt.prune))
+		})
+	}
+
+	// The original minimums are not modified.
+	assert.Equal(t, Minimums{"pkg1": 50, "pkg2": 70, "pkg3": 80}, m)
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 29.0, Truncate(0.29*100), 1e-9)
+	assert.InDelta(t, 72.41, Truncate(72.41379310344827), 1e-9)
+	assert.InDelta(t, 99.99, Truncate(99.999), 1e-9)
+}
+
+func TestLoadSave(t *testing.T) {
+	t.Parallel()
+
+	fsys := memoryfs.New()
+	_, err := Load(fsys, "/.fcov-ratchet")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	m := Minimums{"example.com/pkg2": 5, "pkg1": 72.41}
+	require.NoError(t, Save(fsys, "/.fcov-ratchet", m))
+
+	data, err := vfs.ReadFile(fsys, "/.fcov-ratchet")
+	require.NoError(t, err)
+	assert.Equal(t, header+
+		"example.com/pkg2    5.00\n"+
+		"pkg1               72.41\n", string(data))
+
+	got, err := Load(fsys, "/.fcov-ratchet")
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+
+	require.NoError(t, vfs.WriteFile(fsys, "/.fcov-ratchet", []byte("pkg1 50%\npkg2\n"), 0o644))
+	_, err = Load(fsys, "/.fcov-ratchet")
+	assert.EqualError(t, err, "invalid ratchet entry on line 2: pkg2")
+}
@@ -0,0 +1,222 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"text/template"
+)
+
+// Badge formats.
+const (
+	// Badge renders a flat-style SVG badge with the total coverage.
+	Badge Format = "badge"
+	// BadgeEndpoint renders a shields.io endpoint JSON document with the total
+	// coverage, which can be used by a self-hosted shields.io instance.
+	// See https://shields.io/badges/endpoint-badge
+	BadgeEndpoint Format = "shields"
+)
+
+// DefaultBadgeLabel is the label used by badge formats if no label is set in
+// RenderOptions.BadgeLabel.
+const DefaultBadgeLabel = "coverage"
+
+// badgeColor is a shields.io named color and its hex value.
+type badgeColor struct{ name, hex string }
+
+// badgeColorFor returns the badge background color of a health level.
+func badgeColorFor(h Health) badgeColor {
+	switch h {
+	case HealthCritical:
+		return badgeColor{"critical", "#e05d44"}
+	case HealthWarning:
+		return badgeColor{"yellow", "#dfb317"}
+	default:
+		return badgeColor{"success", "#4c1"}
+	}
+}
+
+// The flat-style badge template, based on the one used by shields.io. Text is
+// rendered at 10x scale for better precision.
+//
+//nolint:lll // SVG markup is kept on as few lines as possible.
+const badgeTmpl = `<svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="20" role="img" aria-label="{{ .Label }}: {{ .Message }}"><title>{{ .Label }}: {{ .Message }}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{ .Width }}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="{{ .LabelWidth }}" height="20" fill="#555"/><rect x="{{ .LabelWidth }}" width="{{ .MessageWidth }}" height="20" fill="{{ .Color }}"/><rect width="{{ .Width }}" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">
+<text aria-hidden="true" x="{{ .LabelX }}" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="{{ .LabelTextLength }}">{{ .Label }}</text><text x="{{ .LabelX }}" y="140" transform="scale(.1)" fill="#fff" textLength="{{ .LabelTextLength }}">{{ .Label }}</text>
+<text aria-hidden="true" x="{{ .MessageX }}" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="{{ .MessageTextLength }}">{{ .Message }}</text><text x="{{ .MessageX }}" y="140" transform="scale(.1)" fill="#fff" textLength="{{ .MessageTextLength }}">{{ .Message }}</text>
+</g></svg>`
+
+// badgeRenderer renders the total coverage as an SVG badge.
+type badgeRenderer struct{}
+
+func (badgeRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	label, message, color := badgeContent(s, opts)
+
+	labelWidth := textWidth(label)
+	messageWidth := textWidth(message)
+	// The horizontal padding around each text segment.
+	const padding = 10
+
+	data := struct {
+		Label, Message, Color              string
+		Width, LabelWidth, MessageWidth    int
+		LabelX, MessageX                   int
+		LabelTextLength, MessageTextLength int
+	}{
+		Label:             html.EscapeString(label),
+		Message:           html.EscapeString(message),
+		Color:             color.hex,
+		LabelWidth:        labelWidth + padding,
+		MessageWidth:      messageWidth + padding,
+		Width:             labelWidth + messageWidth + 2*padding,
+		LabelX:            (labelWidth + padding) * 10 / 2,
+		MessageX:          (labelWidth+padding)*10 + (messageWidth+padding)*10/2,
+		LabelTextLength:   labelWidth * 10,
+		MessageTextLength: messageWidth * 10,
+	}
+
+	tmpl := template.Must(template.New("badge").Parse(badgeTmpl))
+	buf := &strings.Builder{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed rendering badge: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// badgeEndpointRenderer renders the total coverage as a shields.io endpoint
+// JSON document.
+type badgeEndpointRenderer struct{}
+
+func (badgeEndpointRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	label, message, color := badgeContent(s, opts)
+
+	out, err := json.Marshal(struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, label, message, color.name})
+	if err != nil {
+		return "", fmt.Errorf("failed encoding badge endpoint JSON: %w", err)
+	}
+
+	return string(out), nil
+}
+
+func badgeContent(s *Report, opts RenderOptions) (label, message string, color badgeColor) {
+	label = opts.BadgeLabel
+	if label == "" {
+		label = DefaultBadgeLabel
+	}
+	cov := s.Coverage * 100
+
+	return label, fmt.Sprintf("%.2f%%", cov), badgeColorFor(opts.Thresholds.Health(cov))
+}
+
+// textWidth approximates the width in pixels of s rendered in 11px Verdana.
+func textWidth(s string) int {
+	var width float64
+	for _, r := range s {
+		width += verdanaWidth(r)
+	}
+
+	return int(math.Ceil(width))
+}
+
+// verdanaDefaultWidth is the width of characters unknown to verdanaWidth.
+const verdanaDefaultWidth = 7.0
+
+// verdanaWidth returns the approximate width in pixels of r rendered in 11px
+// Verdana.
+func verdanaWidth(r rune) float64 {
+	switch r {
+	case 'i', 'l':
+		return 3.02
+	case 'j':
+		return 3.79
+	case 'f':
+		return 3.86
+	case ' ', ',', '.':
+		return 3.87
+	case '!', 't':
+		return 4.33
+	case 'I':
+		return 4.63
+	case 'r':
+		return 4.69
+	case '(', ')', '-', '/', ':':
+		return 4.99
+	case 'J':
+		return 5.0
+	case 'c', 's':
+		return 5.73
+	case 'z':
+		return 5.84
+	case 'L':
+		return 6.12
+	case 'F':
+		return 6.32
+	case 'k', 'v', 'x', 'y':
+		return 6.51
+	case 'e':
+		return 6.55
+	case 'a':
+		return 6.61
+	case 'P':
+		return 6.63
+	case 'o':
+		return 6.68
+	case 'T', 'Y':
+		return 6.78
+	case 'b', 'd', 'g', 'p', 'q':
+		return 6.85
+	case 'E':
+		return 6.95
+	case 'h', 'n', 'u':
+		return 6.96
+	case '_', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return 6.99
+	case 'A', 'B', 'S', 'V':
+		return 7.52
+	case 'X', 'Z':
+		return 7.53
+	case 'K':
+		return 7.62
+	case 'R':
+		return 7.64
+	case 'C':
+		return 7.66
+	case 'U':
+		return 8.04
+	case 'N':
+		return 8.23
+	case 'H':
+		return 8.27
+	case 'D':
+		return 8.48
+	case 'G':
+		return 8.5
+	case 'O', 'Q':
+		return 8.65
+	case 'w':
+		return 8.99
+	case '+':
+		return 9.17
+	case 'M':
+		return 9.27
+	case 'm':
+		return 10.7
+	case 'W':
+		return 10.88
+	case '%':
+		return 11.84
+	default:
+		return verdanaDefaultWidth
+	}
+}
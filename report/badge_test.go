@@ -0,0 +1,75 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestBadgeRender(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cov      float64
+		label    string
+		expColor string
+		expLabel string
+	}{
+		{"critical/default_label", 0.45, "", "#e05d44", "coverage"},
+		{"warning/custom_label", 0.6, "Total <Coverage>", "#dfb317", "Total &lt;Coverage&gt;"},
+		{"good", 0.9, "cov", "#4c1", "cov"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &Report{Stats: types.Stats{Coverage: tt.cov}}
+			got, err := r.Render(Badge, RenderOptions{
+				Thresholds: Thresholds{Lower: 50, Upper: 75},
+				BadgeLabel: tt.label,
+			})
+			require.NoError(t, err)
+			assert.Contains(t, got, `<svg xmlns="http://www.w3.org/2000/svg"`)
+			assert.Contains(t, got, `fill="`+tt.expColor+`"`)
+			assert.Contains(t, got, ">"+tt.expLabel+"</text>")
+		})
+	}
+
+	t.Run("width", func(t *testing.T) {
+		t.Parallel()
+		r := &Report{Stats: types.Stats{Coverage: 0.4504}}
+		got, err := r.Render(Badge, RenderOptions{Thresholds: Thresholds{Lower: 50, Upper: 75}})
+		require.NoError(t, err)
+		assert.Contains(t, got, `width="115" height="20"`)
+		assert.Contains(t, got, `<rect width="61" height="20" fill="#555"/>`)
+		assert.Contains(t, got, `<rect x="61" width="54" height="20" fill="#e05d44"/>`)
+		assert.Contains(t, got, `x="880" y="140" transform="scale(.1)" fill="#fff" textLength="440">45.04%</text>`)
+	})
+}
+
+func TestBadgeEndpointRender(t *testing.T) {
+	t.Parallel()
+
+	r := &Report{Stats: types.Stats{Coverage: 0.8012}}
+	got, err := r.Render(BadgeEndpoint, RenderOptions{
+		Thresholds: Thresholds{Lower: 50, Upper: 75},
+		BadgeLabel: "Total Coverage",
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"schemaVersion":1,"label":"Total Coverage","message":"80.12%","color":"success"}`,
+		got)
+}
+
+func TestTextWidth(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, textWidth(""))
+	assert.Equal(t, 51, textWidth("coverage"))
+	assert.Equal(t, 7, textWidth("€"))
+}
@@ -0,0 +1,147 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cobertura is the format that renders the report as a Cobertura XML
+// document, which is supported by GitLab and other CI tools to show the
+// coverage of each line in diffs.
+const Cobertura Format = "cobertura"
+
+const coberturaDoctype = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+type coberturaReport struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// coberturaRenderer renders the report as a Cobertura XML document, with the
+// line coverage computed by lineHits. File paths are converted using
+// opts.PathRemaps, which should be used to make them relative to the
+// repository root.
+type coberturaRenderer struct{}
+
+func (coberturaRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	out := coberturaReport{
+		Version:  s.Metadata.Version,
+		Sources:  []string{"."},
+		Packages: []coberturaPackage{},
+	}
+	if !s.Metadata.Timestamp.IsZero() {
+		out.Timestamp = s.Metadata.Timestamp.UnixMilli()
+	}
+
+	pkgNames := make([]string, 0, len(s.Packages))
+	for pkgName := range s.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkgName := range pkgNames {
+		pkg := s.Packages[pkgName]
+		cpkg := coberturaPackage{Name: opts.PathRemaps.Apply(pkgName)}
+		var pkgCovered, pkgValid int
+
+		fnames := make([]string, 0, len(pkg.Files))
+		for fname := range pkg.Files {
+			fnames = append(fnames, fname)
+		}
+		sort.Strings(fnames)
+
+		for _, fname := range fnames {
+			file := pkg.Files[fname]
+			absPath := file.AbsPath()
+			if opts.Filter != nil && opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+
+			class := coberturaClass{
+				Name:     strings.TrimSuffix(fname, ".go"),
+				Filename: opts.PathRemaps.Apply(absPath),
+				Lines:    coberturaLines(file.Blocks),
+			}
+			var covered int
+			for _, l := range class.Lines {
+				if l.Hits > 0 {
+					covered++
+				}
+			}
+			class.LineRate = lineRate(covered, len(class.Lines))
+			pkgCovered += covered
+			pkgValid += len(class.Lines)
+			cpkg.Classes = append(cpkg.Classes, class)
+		}
+
+		if len(cpkg.Classes) == 0 {
+			continue
+		}
+		cpkg.LineRate = lineRate(pkgCovered, pkgValid)
+		out.LinesCovered += pkgCovered
+		out.LinesValid += pkgValid
+		out.Packages = append(out.Packages, cpkg)
+	}
+	out.LineRate = lineRate(out.LinesCovered, out.LinesValid)
+
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed encoding Cobertura report: %w", err)
+	}
+
+	return xml.Header + coberturaDoctype + "\n" + string(data), nil
+}
+
+// coberturaLines returns the Cobertura lines of the blocks.
+func coberturaLines(blocks []Block) []coberturaLine {
+	hits := lineHits(blocks)
+	lines := make([]coberturaLine, 0, len(hits))
+	for _, lh := range hits {
+		lines = append(lines, coberturaLine{Number: lh.Line, Hits: lh.Hits})
+	}
+
+	return lines
+}
+
+func lineRate(covered, valid int) float64 {
+	if valid == 0 {
+		return 0
+	}
+
+	return float64(covered) / float64(valid)
+}
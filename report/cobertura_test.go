@@ -0,0 +1,102 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestCoberturaRender(t *testing.T) {
+	t.Parallel()
+
+	block := func(startLine, endLine, hits int) Block {
+		return Block{
+			FileBlock: types.FileBlock{
+				Start: types.FileLocation{Line: startLine, Col: 1},
+				End:   types.FileLocation{Line: endLine, Col: 2},
+			},
+			NumStatements: 1, HitCount: hits,
+		}
+	}
+
+	report := &Report{
+		Packages: map[string]*Package{
+			"example.com/mod/pkg1": {
+				Name: "example.com/mod/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Name: "file1.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(3, 4, 1), block(4, 5, 0), block(8, 8, 0)},
+					},
+				},
+			},
+			"example.com/mod/pkg2": {
+				Name: "example.com/mod/pkg2",
+				Files: map[string]*File{
+					"file2.go": {
+						Name: "file2.go", Package: "example.com/mod/pkg2",
+						Blocks: []Block{block(1, 2, 3)},
+					},
+				},
+			},
+		},
+		Metadata: Metadata{
+			Version:   "v1.2.3",
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+
+	got, err := report.Render(Cobertura, RenderOptions{
+		PathRemaps: PathRemaps{{From: "example.com/mod/", To: ""}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.6666666666666666" branch-rate="0" lines-covered="4" lines-valid="6" branches-covered="0" branches-valid="0" complexity="0" version="v1.2.3" timestamp="1735787045000">
+  <sources>
+    <source>.</source>
+  </sources>
+  <packages>
+    <package name="pkg1" line-rate="0.5" branch-rate="0" complexity="0">
+      <classes>
+        <class name="file1" filename="pkg1/file1.go" line-rate="0.5" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines>
+            <line number="3" hits="1"></line>
+            <line number="4" hits="1"></line>
+            <line number="5" hits="0"></line>
+            <line number="8" hits="0"></line>
+          </lines>
+        </class>
+      </classes>
+    </package>
+    <package name="pkg2" line-rate="1" branch-rate="0" complexity="0">
+      <classes>
+        <class name="file2" filename="pkg2/file2.go" line-rate="1" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines>
+            <line number="1" hits="3"></line>
+            <line number="2" hits="3"></line>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`, got)
+
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+		got, err := report.Render(Cobertura, RenderOptions{
+			Filter: gitignore.CompileIgnoreLines("*/pkg1/"),
+		})
+		require.NoError(t, err)
+		assert.Contains(t, got, `<coverage line-rate="1" branch-rate="0" lines-covered="2" lines-valid="2"`)
+		assert.NotContains(t, got, "file1.go")
+		assert.Contains(t, got, `filename="example.com/mod/pkg2/file2.go"`)
+	})
+}
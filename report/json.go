@@ -0,0 +1,120 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// JSON is the format that renders the report as a JSON document, including
+// its metadata as top-level fields.
+const JSON Format = "json"
+
+type jsonReport struct {
+	Version    string      `json:"version,omitempty"`
+	GoVersion  string      `json:"go_version,omitempty"`
+	Timestamp  string      `json:"timestamp,omitempty"`
+	Repository string      `json:"repository,omitempty"`
+	Commit     string      `json:"commit,omitempty"`
+	Branch     string      `json:"branch,omitempty"`
+	Inputs     []jsonInput `json:"inputs,omitempty"`
+	jsonStats
+	Packages []jsonPackage `json:"packages"`
+}
+
+type jsonInput struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+type jsonStats struct {
+	NumStatements int     `json:"num_statements"`
+	HitCount      int     `json:"hit_count"`
+	Coverage      float64 `json:"coverage"`
+}
+
+type jsonPackage struct {
+	Name string `json:"name"`
+	jsonStats
+	Files []jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	Name string `json:"name"`
+	jsonStats
+}
+
+// jsonRenderer renders the report as a JSON document.
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	data := s.templateData(opts)
+	meta := data.Metadata
+
+	out := jsonReport{
+		Version:    meta.Version,
+		GoVersion:  meta.GoVersion,
+		Repository: meta.Repository,
+		Commit:     meta.Commit,
+		Branch:     meta.Branch,
+		jsonStats: jsonStats{
+			NumStatements: data.Total.NumStatements,
+			HitCount:      data.Total.HitCount,
+			Coverage:      data.Total.Coverage,
+		},
+		Packages: make([]jsonPackage, 0, len(data.Packages)),
+	}
+	if !meta.Timestamp.IsZero() {
+		out.Timestamp = meta.Timestamp.UTC().Format(time.RFC3339)
+	}
+	for _, in := range meta.Inputs {
+		out.Inputs = append(out.Inputs, jsonInput{Path: in.Path, SHA256: in.SHA256})
+	}
+
+	for _, pkg := range data.Packages {
+		jpkg := jsonPackage{
+			Name: pkg.Name,
+			jsonStats: jsonStats{
+				NumStatements: pkg.NumStatements,
+				HitCount:      pkg.HitCount,
+				Coverage:      pkg.Coverage,
+			},
+			Files: make([]jsonFile, 0, len(pkg.Files)),
+		}
+		for _, file := range pkg.Files {
+			jpkg.Files = append(jpkg.Files, jsonFile{
+				Name: file.Name,
+				jsonStats: jsonStats{
+					NumStatements: file.NumStatements,
+					HitCount:      file.HitCount,
+					Coverage:      file.Coverage,
+				},
+			})
+		}
+		out.Packages = append(out.Packages, jpkg)
+	}
+
+	enc, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed encoding JSON report: %w", err)
+	}
+
+	return string(enc), nil
+}
+
+// ParseJSONTotal returns the global coverage statistics of a report rendered
+// in the JSON format, e.g. to use it as RenderOptions.Baseline.
+func ParseJSONTotal(data []byte) (types.Stats, error) {
+	var r jsonReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return types.Stats{}, fmt.Errorf("failed decoding JSON report: %w", err)
+	}
+
+	return types.Stats{
+		NumStatements: r.NumStatements,
+		HitCount:      r.HitCount,
+		Coverage:      r.Coverage,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestJSONRender(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+		Packages: map[string]*Package{
+			"path/pkg1": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+				Name:  "path/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+						Name:  "file1.go", Package: "path/pkg1",
+					},
+				},
+			},
+			"path/pkg2": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5},
+				Name:  "path/pkg2",
+				Files: map[string]*File{
+					"file2.go": {
+						Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5},
+						Name:  "file2.go", Package: "path/pkg2",
+					},
+				},
+			},
+		},
+		Metadata: Metadata{
+			Version:   "v1.2.3",
+			GoVersion: "go1.24.0",
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Commit:    "abc123",
+			Branch:    "main",
+			Inputs:    []Input{{Path: "coverage.txt", SHA256: "deadbeef"}},
+		},
+	}
+
+	got, err := report.Render(JSON, RenderOptions{
+		Filter:            gitignore.CompileIgnoreLines("*/pkg2"),
+		TrimPackagePrefix: "path/",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "v1.2.3",
+		"go_version": "go1.24.0",
+		"timestamp": "2025-01-02T03:04:05Z",
+		"commit": "abc123",
+		"branch": "main",
+		"inputs": [{"path": "coverage.txt", "sha256": "deadbeef"}],
+		"num_statements": 4,
+		"hit_count": 3,
+		"coverage": 0.75,
+		"packages": [
+			{
+				"name": "pkg1",
+				"num_statements": 2,
+				"hit_count": 2,
+				"coverage": 1,
+				"files": [{"name": "file1.go", "num_statements": 2, "hit_count": 2, "coverage": 1}]
+			}
+		]
+	}`, got)
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		got, err := (&Report{}).Render(JSON, RenderOptions{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"num_statements": 0, "hit_count": 0, "coverage": 0, "packages": []}`, got)
+	})
+}
+
+func TestParseJSONTotal(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseJSONTotal([]byte(`{"num_statements": 4, "hit_count": 3, "coverage": 0.75, "packages": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75}, got)
+
+	_, err = ParseJSONTotal([]byte(`[]`))
+	require.ErrorContains(t, err, "failed decoding JSON report: ")
+}
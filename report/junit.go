@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// JUnit is the format that renders the report as a JUnit XML document, where
+// the total, and the coverage of each package and file is a test case that
+// fails if it's below the lower threshold.
+const JUnit Format = "junit"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitRenderer renders the report as a JUnit XML document. The test suite
+// has a test case for the total coverage, and one for each package and file
+// in the output. Package test cases are named after the package, and file
+// test cases use the package as the class name, so that CI tools group them
+// together.
+type junitRenderer struct{}
+
+func (junitRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	data := s.templateData(opts)
+
+	suite := junitTestSuite{Name: "coverage", Time: "0"}
+	if !s.Metadata.Timestamp.IsZero() {
+		suite.Timestamp = s.Metadata.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	suite.Cases = append(suite.Cases, junitCase("fcov", "Total coverage", data.Total, opts.Thresholds))
+	for _, pkg := range data.Packages {
+		suite.Cases = append(suite.Cases,
+			junitCase("packages", pkg.Name, pkg.Stats, opts.ThresholdsFor(pkg.Path)))
+		for _, file := range pkg.Files {
+			suite.Cases = append(suite.Cases,
+				junitCase(pkg.Name, file.Name, file.Stats, opts.ThresholdsFor(file.Path)))
+		}
+	}
+
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	out := junitTestSuites{
+		Name: "fcov", Tests: suite.Tests, Failures: suite.Failures,
+		Suites: []junitTestSuite{suite},
+	}
+	xmlData, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed encoding JUnit report: %w", err)
+	}
+
+	return xml.Header + string(xmlData), nil
+}
+
+// junitCase returns the test case of the coverage stats. It fails if the
+// coverage is below the lower threshold.
+func junitCase(className, name string, stats types.Stats, th Thresholds) junitTestCase {
+	pct := stats.Coverage * 100
+	summary := fmt.Sprintf("%d of %d %s covered.", stats.HitCount, stats.NumStatements,
+		pluralize(stats.NumStatements, "statement is", "statements are"))
+	tc := junitTestCase{
+		ClassName: className,
+		Name:      name,
+		Time:      "0",
+		SystemOut: fmt.Sprintf("Coverage: %.2f%%. %s", pct, summary),
+	}
+	if th.Health(pct) == HealthCritical {
+		tc.Failure = &junitFailure{
+			Message: fmt.Sprintf("coverage %.2f%% is below the minimum of %.2f%%", pct, th.Lower),
+			Type:    "coverage",
+			Text:    summary,
+		}
+	}
+
+	return tc
+}
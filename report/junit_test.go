@@ -0,0 +1,73 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestJUnitRender(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{NumStatements: 6, HitCount: 3, Coverage: 0.5},
+		Packages: map[string]*Package{
+			"path/pkg1": {
+				Stats: types.Stats{NumStatements: 4, HitCount: 1, Coverage: 0.25},
+				Name:  "path/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Stats: types.Stats{NumStatements: 1, HitCount: 1, Coverage: 1},
+						Name:  "file1.go", Package: "path/pkg1",
+					},
+					"file2.go": {
+						Stats: types.Stats{NumStatements: 3, HitCount: 0, Coverage: 0},
+						Name:  "file2.go", Package: "path/pkg1",
+					},
+				},
+			},
+			"path/pkg2": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+				Name:  "path/pkg2",
+				Files: map[string]*File{
+					"file3.go": {
+						Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+						Name:  "file3.go", Package: "path/pkg2",
+					},
+				},
+			},
+		},
+		Metadata: Metadata{Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	got, err := report.Render(JUnit, RenderOptions{
+		Filter:            gitignore.CompileIgnoreLines("*/pkg2"),
+		Thresholds:        Thresholds{Lower: 50, Upper: 75},
+		ThresholdRules:    ThresholdRules{NewThresholdRule("pkg1", Thresholds{Lower: 20, Upper: 40})},
+		TrimPackagePrefix: "path/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="fcov" tests="4" failures="1">
+  <testsuite name="coverage" tests="4" failures="1" errors="0" time="0" timestamp="2025-01-02T03:04:05Z">
+    <testcase classname="fcov" name="Total coverage" time="0">
+      <system-out>Coverage: 50.00%. 3 of 6 statements are covered.</system-out>
+    </testcase>
+    <testcase classname="packages" name="pkg1" time="0">
+      <system-out>Coverage: 25.00%. 1 of 4 statements are covered.</system-out>
+    </testcase>
+    <testcase classname="pkg1" name="file1.go" time="0">
+      <system-out>Coverage: 100.00%. 1 of 1 statement is covered.</system-out>
+    </testcase>
+    <testcase classname="pkg1" name="file2.go" time="0">
+      <failure message="coverage 0.00% is below the minimum of 20.00%" type="coverage">0 of 3 statements are covered.</failure>
+      <system-out>Coverage: 0.00%. 0 of 3 statements are covered.</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>`, got)
+}
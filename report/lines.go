@@ -0,0 +1,31 @@
+package report
+
+import "sort"
+
+// lineHit is the hit count of a single line of a file.
+type lineHit struct {
+	Line, Hits int
+}
+
+// lineHits converts the blocks into line coverage, for formats that don't
+// support column ranges. Each line spanned by a block is assigned the block's
+// hit count. If a line is spanned by more than one block, the highest hit
+// count is used. The lines are sorted by number.
+func lineHits(blocks []Block) []lineHit {
+	hits := make(map[int]int)
+	for _, b := range blocks {
+		for line := b.Start.Line; line <= b.End.Line; line++ {
+			if h, ok := hits[line]; !ok || b.HitCount > h {
+				hits[line] = b.HitCount
+			}
+		}
+	}
+
+	lines := make([]lineHit, 0, len(hits))
+	for line, h := range hits {
+		lines = append(lines, lineHit{Line: line, Hits: h})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Line < lines[j].Line })
+
+	return lines
+}
@@ -0,0 +1,41 @@
+package report
+
+import (
+	"strconv"
+	"strings"
+)
+
+// linkURL expands the placeholders in the link template tmpl. It returns an
+// empty string if tmpl is empty. The supported placeholders are:
+// - {repo}: the repository name, e.g. "hackfixme/fcov".
+// - {sha}: the commit SHA.
+// - {path}: the file or package path, after applying path remaps.
+// - {line}: the line number.
+func (s *Report) linkURL(opts RenderOptions, path string, line int) string {
+	if opts.LinkTemplate == "" {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{repo}", s.Metadata.Repository,
+		"{sha}", s.Metadata.Commit,
+		"{path}", opts.PathRemaps.Apply(path),
+		"{line}", strconv.Itoa(line),
+	).Replace(opts.LinkTemplate)
+}
+
+// packageURL returns the link to the package directory.
+func (s *Report) packageURL(opts RenderOptions, pkg string) string {
+	return s.linkURL(opts, pkg, 1)
+}
+
+// fileURL returns the link to the file. It points to the first uncovered line
+// of the file, if any.
+func (s *Report) fileURL(opts RenderOptions, f *File) string {
+	line := 1
+	if uncovered := f.UncoveredBlocks(); len(uncovered) > 0 {
+		line = uncovered[0].Start.Line
+	}
+
+	return s.linkURL(opts, f.AbsPath(), line)
+}
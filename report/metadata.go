@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The length of abbreviated commit SHAs.
+const shortSHALen = 10
+
+// Metadata holds information about the report itself, which allows tracing it
+// back to its source.
+type Metadata struct {
+	// Version of fcov that created the report.
+	Version string
+	// GoVersion is the version of the Go runtime fcov was built with.
+	GoVersion string
+	// Timestamp of when the report was created.
+	Timestamp time.Time
+	// Repository name, e.g. "hackfixme/fcov".
+	Repository string
+	// Commit SHA the report was created for.
+	Commit string
+	// Branch the report was created for.
+	Branch string
+	// Inputs are the coverage files the report was created from.
+	Inputs []Input
+}
+
+// Input is a coverage file the report was created from.
+type Input struct {
+	Path string
+	// SHA256 is the hex-encoded SHA-256 digest of the file contents.
+	SHA256 string
+}
+
+// String returns a single line summary of the metadata, omitting any empty
+// values, e.g. "Commit abc1234 on branch main, created on 2025-01-02 03:04:05
+// UTC with fcov v1.0.0". The commit SHA is abbreviated.
+func (m Metadata) String() string {
+	var parts []string
+	if m.Commit != "" {
+		sha := m.Commit
+		if len(sha) > shortSHALen {
+			sha = sha[:shortSHALen]
+		}
+		commit := fmt.Sprintf("Commit %s", sha)
+		if m.Repository != "" {
+			commit = fmt.Sprintf("Commit %s@%s", m.Repository, sha)
+		}
+		if m.Branch != "" {
+			commit += fmt.Sprintf(" on branch %s", m.Branch)
+		}
+		parts = append(parts, commit)
+	} else if m.Branch != "" {
+		parts = append(parts, fmt.Sprintf("Branch %s", m.Branch))
+	}
+
+	var created []string
+	if !m.Timestamp.IsZero() {
+		created = append(created,
+			fmt.Sprintf("on %s", m.Timestamp.UTC().Format("2006-01-02 15:04:05 MST")))
+	}
+	if m.Version != "" {
+		created = append(created, fmt.Sprintf("with fcov %s", m.Version))
+	}
+	if len(created) > 0 {
+		if len(parts) == 0 {
+			parts = append(parts, "Created "+strings.Join(created, " "))
+		} else {
+			parts = append(parts, "created "+strings.Join(created, " "))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
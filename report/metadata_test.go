@@ -0,0 +1,54 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataString(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		meta Metadata
+		want string
+	}{
+		{"empty", Metadata{}, ""},
+		{
+			name: "full",
+			meta: Metadata{
+				Version: "v1.2.3", Timestamp: ts, Repository: "org/repo",
+				Commit: "0123456789abcdef", Branch: "main",
+			},
+			want: "Commit org/repo@0123456789 on branch main, " +
+				"created on 2025-01-02 03:04:05 UTC with fcov v1.2.3",
+		},
+		{
+			name: "commit_only",
+			meta: Metadata{Commit: "abc123"},
+			want: "Commit abc123",
+		},
+		{
+			name: "branch_version",
+			meta: Metadata{Branch: "dev", Version: "v1.2.3"},
+			want: "Branch dev, created with fcov v1.2.3",
+		},
+		{
+			name: "timestamp_only",
+			meta: Metadata{Timestamp: ts.In(time.FixedZone("X", 3600))},
+			want: "Created on 2025-01-02 03:04:05 UTC",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.meta.String())
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// OpenMetrics is the format that renders the report as gauges in the
+// OpenMetrics text exposition format, which is also accepted by Prometheus and
+// the node_exporter textfile collector.
+// See https://prometheus.io/docs/specs/om/open_metrics_spec/
+const OpenMetrics Format = "openmetrics"
+
+// metricFamily is a gauge metric, and its value for the stats of a row of the
+// report.
+type metricFamily struct {
+	name, help string
+	value      func(stats types.Stats) float64
+}
+
+// metricFamilies returns the metrics rendered for each row of the report.
+func metricFamilies() []metricFamily {
+	return []metricFamily{
+		{
+			name: "fcov_coverage_ratio", help: "Ratio of statements covered by tests.",
+			value: func(stats types.Stats) float64 { return stats.Coverage },
+		},
+		{
+			name: "fcov_statements_total", help: "Number of statements.",
+			value: func(stats types.Stats) float64 { return float64(stats.NumStatements) },
+		},
+		{
+			name: "fcov_statements_covered", help: "Number of statements covered by tests.",
+			value: func(stats types.Stats) float64 { return float64(stats.HitCount) },
+		},
+	}
+}
+
+// openMetricsRenderer renders the report as OpenMetrics gauges. Each metric
+// has a sample with the "total" scope, and a sample for each package and file
+// in the output, with the "package" and "file" scopes. The labels in
+// opts.MetricLabels are added to every sample.
+type openMetricsRenderer struct{}
+
+func (openMetricsRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	if err := ValidateMetricLabels(opts.MetricLabels); err != nil {
+		return "", err
+	}
+
+	extraLabels := make([][2]string, 0, len(opts.MetricLabels))
+	for name, value := range opts.MetricLabels {
+		extraLabels = append(extraLabels, [2]string{name, value})
+	}
+	sort.Slice(extraLabels, func(i, j int) bool { return extraLabels[i][0] < extraLabels[j][0] })
+
+	rows := s.StatsRows(opts)
+
+	var buf strings.Builder
+	for _, mf := range metricFamilies() {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", mf.name, mf.help)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", mf.name)
+		for _, row := range rows {
+			fmt.Fprintf(&buf, "%s{%s} %s\n", mf.name,
+				metricLabels(append(rowLabels(row), extraLabels...)),
+				strconv.FormatFloat(mf.value(row.Stats), 'g', -1, 64))
+		}
+	}
+	buf.WriteString("# EOF\n")
+
+	return buf.String(), nil
+}
+
+// rowLabels returns the scope, package and file labels of the row.
+func rowLabels(row StatsRow) [][2]string {
+	labels := [][2]string{{"scope", row.Scope}}
+	if row.Package != "" {
+		labels = append(labels, [2]string{"package", row.Package})
+	}
+	if row.File != "" {
+		labels = append(labels, [2]string{"file", row.File})
+	}
+
+	return labels
+}
+
+// metricLabelName is the format of valid label names.
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateMetricLabels returns an error if the name of any of the labels is
+// not a valid OpenMetrics label name, or is reserved. The names of the labels
+// added by the renderer, and names starting with "__", are reserved.
+func ValidateMetricLabels(labels map[string]string) error {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch {
+		case !metricLabelName.MatchString(name):
+			return fmt.Errorf("invalid metric label name '%s': must match %s", name, metricLabelName)
+		case name == "scope", name == "package", name == "file", strings.HasPrefix(name, "__"):
+			return fmt.Errorf("metric label name '%s' is reserved", name)
+		}
+	}
+
+	return nil
+}
+
+// metricLabels returns the labels formatted as a comma-separated list of
+// name="value" pairs, with the values escaped.
+func metricLabels(labels [][2]string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l[0], escaper.Replace(l[1]))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,127 @@
+package report
+
+import (
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestOpenMetricsRender(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+		Packages: map[string]*Package{
+			"path/pkg1": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+				Name:  "path/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1},
+						Name:  "file1.go", Package: "path/pkg1",
+					},
+				},
+			},
+			"path/pkg2": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5},
+				Name:  "path/pkg2",
+				Files: map[string]*File{
+					"file2.go": {
+						Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5},
+						Name:  "file2.go", Package: "path/pkg2",
+					},
+				},
+			},
+		},
+	}
+
+	got, err := report.Render(OpenMetrics, RenderOptions{
+		Filter:            gitignore.CompileIgnoreLines("*/pkg2"),
+		TrimPackagePrefix: "path/",
+		MetricLabels:      map[string]string{"repo": "org/repo", "branch": `fix/"quoted"`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP fcov_coverage_ratio Ratio of statements covered by tests.
+# TYPE fcov_coverage_ratio gauge
+fcov_coverage_ratio{scope="total",branch="fix/\"quoted\"",repo="org/repo"} 0.75
+fcov_coverage_ratio{scope="package",package="pkg1",branch="fix/\"quoted\"",repo="org/repo"} 1
+fcov_coverage_ratio{scope="file",package="pkg1",file="pkg1/file1.go",branch="fix/\"quoted\"",repo="org/repo"} 1
+# HELP fcov_statements_total Number of statements.
+# TYPE fcov_statements_total gauge
+fcov_statements_total{scope="total",branch="fix/\"quoted\"",repo="org/repo"} 4
+fcov_statements_total{scope="package",package="pkg1",branch="fix/\"quoted\"",repo="org/repo"} 2
+fcov_statements_total{scope="file",package="pkg1",file="pkg1/file1.go",branch="fix/\"quoted\"",repo="org/repo"} 2
+# HELP fcov_statements_covered Number of statements covered by tests.
+# TYPE fcov_statements_covered gauge
+fcov_statements_covered{scope="total",branch="fix/\"quoted\"",repo="org/repo"} 3
+fcov_statements_covered{scope="package",package="pkg1",branch="fix/\"quoted\"",repo="org/repo"} 2
+fcov_statements_covered{scope="file",package="pkg1",file="pkg1/file1.go",branch="fix/\"quoted\"",repo="org/repo"} 2
+# EOF
+`, got)
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		got, err := (&Report{}).Render(OpenMetrics, RenderOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, `# HELP fcov_coverage_ratio Ratio of statements covered by tests.
+# TYPE fcov_coverage_ratio gauge
+fcov_coverage_ratio{scope="total"} 0
+# HELP fcov_statements_total Number of statements.
+# TYPE fcov_statements_total gauge
+fcov_statements_total{scope="total"} 0
+# HELP fcov_statements_covered Number of statements covered by tests.
+# TYPE fcov_statements_covered gauge
+fcov_statements_covered{scope="total"} 0
+# EOF
+`, got)
+	})
+}
+
+func TestValidateMetricLabels(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		labels map[string]string
+		expErr string
+	}{
+		{name: "ok/empty"},
+		{name: "ok/valid", labels: map[string]string{"repo": "org/repo", "_Branch2": "main"}},
+		{
+			name: "err/invalid_start", labels: map[string]string{"2repo": "x"},
+			expErr: "invalid metric label name '2repo': must match ^[a-zA-Z_][a-zA-Z0-9_]*$",
+		},
+		{
+			name: "err/invalid_char", labels: map[string]string{"repo-name": "x"},
+			expErr: "invalid metric label name 'repo-name': must match ^[a-zA-Z_][a-zA-Z0-9_]*$",
+		},
+		{
+			name: "err/empty_name", labels: map[string]string{"": "x"},
+			expErr: "invalid metric label name '': must match ^[a-zA-Z_][a-zA-Z0-9_]*$",
+		},
+		{name: "err/reserved_scope", labels: map[string]string{"scope": "x"}, expErr: "metric label name 'scope' is reserved"},
+		{name: "err/reserved_package", labels: map[string]string{"package": "x"}, expErr: "metric label name 'package' is reserved"},
+		{name: "err/reserved_file", labels: map[string]string{"file": "x"}, expErr: "metric label name 'file' is reserved"},
+		{name: "err/reserved_prefix", labels: map[string]string{"__name__": "x"}, expErr: "metric label name '__name__' is reserved"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateMetricLabels(tt.labels)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				_, err = (openMetricsRenderer{}).Render(&Report{}, RenderOptions{MetricLabels: tt.labels})
+				require.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
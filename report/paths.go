@@ -0,0 +1,43 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathRemap replaces the From prefix of a path with To.
+type PathRemap struct {
+	From, To string
+}
+
+// UnmarshalText parses a path remap in the form of "<from>=<to>".
+func (pr *PathRemap) UnmarshalText(text []byte) error {
+	from, to, ok := strings.Cut(string(text), "=")
+	if !ok || from == "" {
+		return fmt.Errorf("invalid path remap value: %s", text)
+	}
+	pr.From, pr.To = from, to
+
+	return nil
+}
+
+// PathRemaps is an ordered list of path prefix replacements. They're used to
+// convert the package paths in coverage files into file paths relative to the
+// repository root, e.g. "go.hackfix.me/fcov/" to "".
+type PathRemaps []PathRemap
+
+// Apply replaces the prefix of p using the first remap that matches it. If no
+// remap matches, p is returned unchanged. A From value with a trailing slash
+// also matches the directory path itself, e.g. "pkg/" matches "pkg".
+func (prs PathRemaps) Apply(p string) string {
+	for _, pr := range prs {
+		if rest, ok := strings.CutPrefix(p, pr.From); ok {
+			return pr.To + rest
+		}
+		if strings.HasSuffix(pr.From, "/") && p == strings.TrimSuffix(pr.From, "/") {
+			return strings.TrimSuffix(pr.To, "/")
+		}
+	}
+
+	return p
+}
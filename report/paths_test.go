@@ -0,0 +1,53 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathRemapsApply(t *testing.T) {
+	t.Parallel()
+
+	remaps := PathRemaps{
+		{From: "go.hackfix.me/fcov/", To: ""},
+		{From: "example.com/mod/", To: "src/"},
+		{From: "example.com/", To: "other/"},
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"go.hackfix.me/fcov/report/render.go", "report/render.go"},
+		{"go.hackfix.me/fcov/report", "report"},
+		{"go.hackfix.me/fcov", ""},
+		{"example.com/mod/pkg/file.go", "src/pkg/file.go"},
+		{"example.com/mod", "src"},
+		{"example.com/other/file.go", "other/other/file.go"},
+		{"unknown/file.go", "unknown/file.go"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, remaps.Apply(tt.input))
+		})
+	}
+}
+
+func TestPathRemapUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var pr PathRemap
+	require.NoError(t, pr.UnmarshalText([]byte("go.hackfix.me/fcov/=")))
+	assert.Equal(t, PathRemap{From: "go.hackfix.me/fcov/", To: ""}, pr)
+
+	require.NoError(t, pr.UnmarshalText([]byte("a=b=c")))
+	assert.Equal(t, PathRemap{From: "a", To: "b=c"}, pr)
+
+	assert.EqualError(t, pr.UnmarshalText([]byte("nope")), "invalid path remap value: nope")
+	assert.EqualError(t, pr.UnmarshalText([]byte("=to")), "invalid path remap value: =to")
+}
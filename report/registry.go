@@ -0,0 +1,96 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registry holds the renderers available for each format, and the file
+// extensions that map to each format.
+//
+//nolint:gochecknoglobals // The registry must be reachable from library code.
+var registry = struct {
+	mx        sync.RWMutex
+	renderers map[Format]Renderer
+	exts      map[string]Format
+}{
+	renderers: map[Format]Renderer{
+		Text:          textRenderer{},
+		Markdown:      markdownRenderer{},
+		Template:      templateRenderer{},
+		JSON:          jsonRenderer{},
+		Badge:         badgeRenderer{},
+		BadgeEndpoint: badgeEndpointRenderer{},
+		Cobertura:     coberturaRenderer{},
+		SARIF:         sarifRenderer{},
+		JUnit:         junitRenderer{},
+		SonarQube:     sonarQubeRenderer{},
+		OpenMetrics:   openMetricsRenderer{},
+	},
+	exts: map[string]Format{
+		"svg":  Badge,
+		"prom": OpenMetrics,
+	},
+}
+
+// Register makes a renderer available for the given format, replacing any
+// renderer previously registered for it. The format name can be used as an
+// output value or a file extension, and any additional file extensions given
+// will be inferred as this format.
+func Register(ft Format, r Renderer, exts ...string) {
+	registry.mx.Lock()
+	defer registry.mx.Unlock()
+
+	registry.renderers[ft] = r
+	for _, ext := range exts {
+		registry.exts[strings.TrimPrefix(ext, ".")] = ft
+	}
+}
+
+// Formats returns the names of all registered formats, sorted alphabetically.
+func Formats() []Format {
+	registry.mx.RLock()
+	defer registry.mx.RUnlock()
+
+	formats := make([]Format, 0, len(registry.renderers))
+	for ft := range registry.renderers {
+		formats = append(formats, ft)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i] < formats[j] })
+
+	return formats
+}
+
+// FormatFromString parses s into a registered Format value. It returns an
+// empty Format if no renderer is registered for s.
+func FormatFromString(s string) Format {
+	if lookupRenderer(Format(s)) == nil {
+		return ""
+	}
+
+	return Format(s)
+}
+
+// FormatFromExtension returns the registered Format that corresponds to the
+// file extension ext, with or without the leading dot. It returns an empty
+// Format if the extension is unknown.
+func FormatFromExtension(ext string) Format {
+	ext = strings.TrimPrefix(ext, ".")
+
+	registry.mx.RLock()
+	ft, ok := registry.exts[ext]
+	registry.mx.RUnlock()
+	if ok {
+		return ft
+	}
+
+	return FormatFromString(ext)
+}
+
+func lookupRenderer(ft Format) Renderer {
+	registry.mx.RLock()
+	defer registry.mx.RUnlock()
+
+	return registry.renderers[ft]
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	const custom Format = "test-custom"
+	Register(custom, RendererFunc(func(r *Report, opts RenderOptions) (string, error) {
+		return "custom render", nil
+	}), ".cst")
+
+	assert.Equal(t, custom, FormatFromString("test-custom"))
+	assert.Equal(t, custom, FormatFromExtension(".cst"))
+	assert.Equal(t, custom, FormatFromExtension("test-custom"))
+	assert.Contains(t, Formats(), custom)
+
+	got, err := (&Report{}).Render(custom, RenderOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "custom render", got)
+}
+
+func TestFormatFromExtension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  Format
+	}{
+		{"txt", Text},
+		{".md", Markdown},
+		{".unknown", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got := FormatFromExtension(tt.input)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,110 +3,288 @@ package report
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/olekukonko/tablewriter"
 	gitignore "github.com/sabhiram/go-gitignore"
+
+	"go.hackfix.me/fcov/types"
 )
 
 // Format is the type of format a report can be rendered in.
 type Format string
 
-// Supported format types.
+// Built-in format types.
 const (
 	Text     Format = "txt"
 	Markdown Format = "md"
 )
 
+// TotalCoverageRegex matches the line returned by TotalCoverageLine, and
+// captures the coverage percentage. It can be used as the GitLab CI coverage
+// regex, e.g. `coverage: '/Total Coverage: \d+\.\d+%/'`.
+const TotalCoverageRegex = `Total Coverage: (\d+\.\d+)%`
+
 // Marker used to distinguish package from file paths in the pre-rendered output.
 const pkgMarker = '\x00'
 
-// Render the report as a string in the provided format, applying the provided
-// filter, and style adjustments.
-// The lower and upper coverage thresholds are used by formats like Markdown to
-// apply different colors depending on their values. trimPackagePrefix will
-// remove the matching prefix from the absolute file path.
-func (s *Report) Render(
-	ft Format, nestFiles bool, filter *gitignore.GitIgnore,
-	lowerThreshold, upperThreshold float64, trimPackagePrefix string,
-) string {
+// Renderer renders a report in a specific format.
+type Renderer interface {
+	Render(r *Report, opts RenderOptions) (string, error)
+}
+
+// RendererFunc is an adapter to allow the use of ordinary functions as
+// renderers.
+type RendererFunc func(r *Report, opts RenderOptions) (string, error)
+
+// Render calls f(r, opts).
+func (f RendererFunc) Render(r *Report, opts RenderOptions) (string, error) {
+	return f(r, opts)
+}
+
+// TotalCoverageLine returns the line with the total coverage percentage of
+// the coverage ratio cov, as written by the text format, e.g.
+// "Total Coverage: 45.04%". Its format is stable, so that it can be parsed by
+// CI tools with TotalCoverageRegex.
+func TotalCoverageLine(cov float64) string {
+	return fmt.Sprintf("Total Coverage: %.2f%%", cov*100)
+}
+
+// RenderOptions are the options that change how a report is rendered.
+// Renderers are free to ignore options that don't apply to their format.
+type RenderOptions struct {
+	// NestFiles nests files under their package, instead of rendering each
+	// file on its own line with its absolute path.
+	NestFiles bool
+	// Filter excludes matching package and file paths from the output. A nil
+	// value doesn't exclude anything.
+	Filter *gitignore.GitIgnore
+	// Thresholds are used by formats like Markdown to apply different colors
+	// depending on the coverage percentage.
+	Thresholds Thresholds
+	// ThresholdRules override Thresholds for the packages and files whose
+	// paths match their pattern. See ThresholdsFor.
+	ThresholdRules ThresholdRules
+	// HealthIndicators adds an indicator of the health of each package and
+	// file row, using the thresholds returned by ThresholdsFor. Markdown rows
+	// get an icon, and text rows are colored if Color is true.
+	HealthIndicators bool
+	// Color enables ANSI colors in the text format, e.g. when it's written to
+	// a terminal.
+	Color bool
+	// TrimPackagePrefix is removed from the package path in the output.
+	TrimPackagePrefix string
+	// Template is the text/template source executed by the Template format.
+	Template string
+	// BadgeLabel is the label text of badge formats. DefaultBadgeLabel is used
+	// if it's empty.
+	BadgeLabel string
+	// LinkTemplate is a URL template used to link package and file names to
+	// their source, e.g. "https://github.com/{repo}/blob/{sha}/{path}#L{line}".
+	// See linkURL for the supported placeholders.
+	LinkTemplate string
+	// PathRemaps convert package and file paths into paths relative to the
+	// repository root, for use in links.
+	PathRemaps PathRemaps
+	// ShowMetadata appends the report metadata to the output of formats that
+	// don't include it by default, like text and Markdown.
+	ShowMetadata bool
+	// Baseline holds the global coverage statistics of a previous report, used
+	// to compute the coverage delta in templates. See ParseJSONTotal.
+	Baseline *types.Stats
+	// MetricLabels are extra labels added to every sample of the OpenMetrics
+	// format, e.g. the repository and branch.
+	MetricLabels map[string]string
+	// MaxSize is the maximum number of characters of the Markdown output. If
+	// the output would exceed it, the report is progressively reduced in
+	// detail until it fits. A value of 0 disables the limit.
+	MaxSize int
+}
+
+// ThresholdsFor returns the thresholds of a package or file path, which are
+// those of the first threshold rule that matches the full path, the path with
+// TrimPackagePrefix removed, or the path with PathRemaps applied. If no rule
+// matches, it returns Thresholds.
+func (o RenderOptions) ThresholdsFor(path string) Thresholds {
+	return o.ThresholdRules.For(o.Thresholds, path,
+		strings.TrimPrefix(path, o.TrimPackagePrefix), o.PathRemaps.Apply(path))
+}
+
+// Render the report as a string in the provided format. It returns an error if
+// no renderer is registered for the format.
+func (s *Report) Render(ft Format, opts RenderOptions) (string, error) {
+	r := lookupRenderer(ft)
+	if r == nil {
+		return "", fmt.Errorf("unsupported report format: %s", ft)
+	}
+
+	if opts.Filter == nil {
+		opts.Filter = gitignore.CompileIgnoreLines()
+	}
+
+	return r.Render(s, opts)
+}
+
+// textRenderer renders the report as a plain text table.
+type textRenderer struct{}
+
+func (textRenderer) Render(s *Report, opts RenderOptions) (string, error) {
 	if len(s.Packages) == 0 {
-		return ""
+		return "", nil
 	}
 
-	sum := s.preRender(filter, nestFiles, trimPackagePrefix)
+	sum := s.preRender(opts)
 
 	buf := &strings.Builder{}
-	table := tablewriter.NewWriter(buf)
+	table := newTable(buf)
+	table.SetColumnSeparator("")
+	table.SetNoWhiteSpace(true)
+	table.SetBorder(false)
+
 	data := [][]string{}
+	if opts.NestFiles {
+		renderTextNested(sum, &data)
+	} else {
+		data = sum
+	}
 
-	switch ft {
-	case Text:
-		table.SetColumnSeparator("")
-		table.SetNoWhiteSpace(true)
-		table.SetBorder(false)
-		if nestFiles {
-			renderTextNested(sum, &data)
-		} else {
-			data = sum
-		}
-	case Markdown:
-		table.SetCenterSeparator("|")
-		table.SetAutoFormatHeaders(false)
-		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-		table.SetCenterSeparator("|")
+	renderTable(table, data)
+	total := TotalCoverageLine(s.Coverage)
+	if opts.HealthIndicators && opts.Color {
+		total = opts.Thresholds.Health(s.Coverage*100).ansiColor() + total + ansiReset
+	}
+	buf.WriteString("\n" + total)
+	if meta := s.Metadata.String(); opts.ShowMetadata && meta != "" {
+		buf.WriteString(fmt.Sprintf("\n\n%s", meta))
+	}
 
-		buf.Write([]byte(fmt.Sprintf("![Total Coverage](%s)\n\n",
-			generateBadgeURL(s.Coverage*100, lowerThreshold, upperThreshold))))
+	return trimTableOutput(buf.String()), nil
+}
 
-		if len(sum) == 0 {
-			break
-		}
+// markdownRenderer renders the report as a Markdown table, preceded by a
+// badge with the total coverage.
+type markdownRenderer struct{}
 
+func (markdownRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	if len(s.Packages) == 0 {
+		return "", nil
+	}
+
+	// Markdown rows show health indicators as icons instead of colors.
+	opts.Color = false
+	groups := s.preRenderGroups(opts)
+	out := s.renderMarkdownGroups(groups, opts, "")
+	if opts.MaxSize > 0 && utf8.RuneCountInString(out) > opts.MaxSize {
+		out = s.truncateMarkdown(groups, opts)
+	}
+
+	return out, nil
+}
+
+// renderMarkdownGroups renders the Markdown table of the grouped lines, and
+// appends note to the output if it's not empty.
+func (s *Report) renderMarkdownGroups(groups []rowGroup, opts RenderOptions, note string) string {
+	sum := flattenGroups(groups, true)
+
+	buf := &strings.Builder{}
+	table := newTable(buf)
+	table.SetCenterSeparator("|")
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+
+	buf.WriteString(fmt.Sprintf("![Total Coverage](%s)\n\n",
+		generateBadgeURL(s.Coverage*100, opts.Thresholds.Lower, opts.Thresholds.Upper)))
+
+	data := [][]string{}
+	if len(sum) > 0 {
 		// Set the headers manually instead of using table.SetHeader because it
 		// doesn't support GitHub's column alignment syntax.
 		// See https://github.com/olekukonko/tablewriter/pull/181
 		data = append(data, []string{"Package", "Coverage"},
 			[]string{":------", "-------:"})
 
-		if nestFiles {
+		if opts.NestFiles {
 			renderMarkdownNested(sum, &data)
 		} else {
 			renderMarkdown(sum, &data)
 		}
 	}
 
+	renderTable(table, data)
+
+	out := trimTableOutput(buf.String())
+	if note != "" {
+		out = fmt.Sprintf("%s\n\n> **Note**: %s", strings.TrimRight(out, "\n"), note)
+	}
+	if meta := s.Metadata.String(); opts.ShowMetadata && meta != "" {
+		out = fmt.Sprintf("%s\n\n<sub>%s</sub>", strings.TrimRight(out, "\n"), meta)
+	}
+
+	return out
+}
+
+func newTable(buf *strings.Builder) *tablewriter.Table {
+	table := tablewriter.NewWriter(buf)
 	table.SetAutoWrapText(false)
 	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT})
 	table.SetTablePadding(" ")
+
+	return table
+}
+
+func renderTable(table *tablewriter.Table, data [][]string) {
 	table.AppendBulk(data)
 	table.Render()
+}
 
-	if ft == Text {
-		buf.Write([]byte(fmt.Sprintf("\nTotal Coverage: %.2f%%", s.Coverage*100)))
-	}
-
-	out := buf.String()
-	// tablewriter appends an extra newline at the end that I can't seem to
-	// disable, so remove it.
+// trimTableOutput removes the extra newline that tablewriter appends at the
+// end, which I can't seem to disable.
+func trimTableOutput(out string) string {
 	out, _ = strings.CutSuffix(out, "\n")
-
 	return out
 }
 
-// preRender sorts and flattens the report, applying any filters, and
-// optionally trimming the file paths as needed.
-func (s *Report) preRender(filter *gitignore.GitIgnore, nestFiles bool, trimPackagePrefix string) [][]string {
+// preRender sorts and flattens the report for the text format, applying any
+// filters, and optionally trimming the file paths as needed.
+func (s *Report) preRender(opts RenderOptions) [][]string {
+	return flattenGroups(s.preRenderGroups(RenderOptions{
+		Filter: opts.Filter, NestFiles: opts.NestFiles, TrimPackagePrefix: opts.TrimPackagePrefix,
+		Thresholds: opts.Thresholds, ThresholdRules: opts.ThresholdRules, PathRemaps: opts.PathRemaps,
+		// Text rows only show health indicators as colors.
+		HealthIndicators: opts.HealthIndicators && opts.Color, Color: opts.Color,
+	}), false)
+}
+
+// rowGroup holds the pre-rendered lines of a package and its files.
+type rowGroup struct {
+	pkg     *Package
+	pkgLine []string
+	// showPkg is false if the package line should be omitted from the output.
+	showPkg bool
+	files   [][]string
+	// URLs of the package and each file, if a link template is set.
+	pkgURL   string
+	fileURLs []string
+}
+
+// preRenderGroups sorts the report and groups the lines of each package,
+// applying any filters, and optionally trimming the file paths as needed.
+// Packages without any lines in the output are omitted.
+func (s *Report) preRenderGroups(opts RenderOptions) []rowGroup {
+	filter, nestFiles, trimPackagePrefix := opts.Filter, opts.NestFiles, opts.TrimPackagePrefix
+
 	pkgNames := make([]string, 0, len(s.Packages))
 	for pkgName := range s.Packages {
 		pkgNames = append(pkgNames, pkgName)
 	}
 	sort.Strings(pkgNames)
 
-	sum := make([][]string, 0)
+	groups := make([]rowGroup, 0, len(pkgNames))
 	for _, pkgName := range pkgNames {
 		pkgSum := s.Packages[pkgName]
 
@@ -117,6 +295,7 @@ func (s *Report) preRender(filter *gitignore.GitIgnore, nestFiles bool, trimPack
 		sort.Strings(fnames)
 
 		pkgFiles := make([][]string, 0, len(pkgSum.Files))
+		fileURLs := make([]string, 0, len(pkgSum.Files))
 		for _, fname := range fnames {
 			file := pkgSum.Files[fname]
 			absPath := file.AbsPath()
@@ -126,23 +305,66 @@ func (s *Report) preRender(filter *gitignore.GitIgnore, nestFiles bool, trimPack
 			if !nestFiles {
 				fname = strings.TrimPrefix(absPath, trimPackagePrefix)
 			}
-			fileCov := strconv.FormatFloat(file.Coverage*100, 'f', 2, 64)
-			pkgFiles = append(pkgFiles, []string{fname, fmt.Sprintf("%s%%", fileCov)})
+			pkgFiles = append(pkgFiles, []string{fname, opts.coverageCell(absPath, file.Coverage)})
+			fileURLs = append(fileURLs, s.fileURL(opts, file))
+		}
+
+		// HACK: Mark package lines with a prefix marker, so that they can
+		// be distinguished during final rendering. Otherwise the sum data
+		// structure would have to be more complicated.
+		pkgLine := []string{
+			string(pkgMarker) + strings.TrimPrefix(pkgName, trimPackagePrefix),
+			opts.coverageCell(pkgName, pkgSum.Coverage),
+		}
+		showPkg := !filter.MatchesPath(pkgName) || (nestFiles && len(pkgFiles) > 0)
+		if !showPkg && len(pkgFiles) == 0 {
+			continue
+		}
+
+		groups = append(groups, rowGroup{
+			pkg: pkgSum, pkgLine: pkgLine, showPkg: showPkg, files: pkgFiles,
+			pkgURL: s.packageURL(opts, pkgName), fileURLs: fileURLs,
+		})
+	}
+
+	return groups
+}
+
+// coverageCell returns the coverage ratio cov of a package or file path
+// formatted as a percentage, with a health indicator if enabled.
+func (o RenderOptions) coverageCell(path string, cov float64) string {
+	cell := fmt.Sprintf("%s%%", strconv.FormatFloat(cov*100, 'f', 2, 64))
+	if !o.HealthIndicators {
+		return cell
+	}
+
+	health := o.ThresholdsFor(path).Health(cov * 100)
+	if o.Color {
+		return health.ansiColor() + cell + ansiReset
+	}
+
+	return health.icon() + " " + cell
+}
+
+// flattenGroups returns the lines of all groups. If withURLs is true, each
+// line has a third element with its URL, which can be empty.
+func flattenGroups(groups []rowGroup, withURLs bool) [][]string {
+	line := func(l []string, url string) []string {
+		// Copy the line, since the final rendering modifies it.
+		if withURLs {
+			return []string{l[0], l[1], url}
 		}
+		return []string{l[0], l[1]}
+	}
 
-		if !filter.MatchesPath(pkgName) || (nestFiles && len(pkgFiles) > 0) {
-			pkgName = strings.TrimPrefix(pkgName, trimPackagePrefix)
-
-			// HACK: Mark package lines with a prefix marker, so that they can
-			// be distinguished during final rendering. Otherwise the sum data
-			// structure would have to be more complicated.
-			pkgName = string(pkgMarker) + pkgName
-			sum = append(sum, []string{
-				pkgName,
-				fmt.Sprintf("%s%%", strconv.FormatFloat(pkgSum.Coverage*100, 'f', 2, 64)),
-			})
+	sum := make([][]string, 0)
+	for _, g := range groups {
+		if g.showPkg {
+			sum = append(sum, line(g.pkgLine, g.pkgURL))
+		}
+		for i, f := range g.files {
+			sum = append(sum, line(f, g.fileURLs[i]))
 		}
-		sum = append(sum, pkgFiles...)
 	}
 
 	return sum
@@ -155,20 +377,22 @@ func renderMarkdown(sum [][]string, data *[][]string) {
 		if line[0][0] == pkgMarker {
 			line[0] = line[0][1:]
 		}
-		line[0] = fmt.Sprintf("`%s`", line[0])
-		*data = append(*data, []string{line[0], line[1]})
+		*data = append(*data, []string{markdownName(line), line[1]})
 	}
 }
 
 func renderMarkdownNested(sum [][]string, data *[][]string) {
-	pkgDataTmpl := "<details><summary>`%s`</summary>%s</details>"
-	tableTmpl := "<table>{{range .}}<tr><td>`{{index . 0}}`</td>" +
+	pkgDataTmpl := "<details><summary>%s</summary>%s</details>"
+	tableTmpl := "<table>{{range .}}<tr><td>{{name .}}</td>" +
 		"<td>{{index . 1}}</td></tr>{{end}}" +
 		"</table>"
-	tmpl := template.Must(template.New("table").Parse(tableTmpl))
+	tmpl := template.Must(template.New("table").
+		Funcs(template.FuncMap{"name": markdownName}).
+		Parse(tableTmpl))
 
 	var (
 		pkgName, pkgCov string
+		pkgLine         []string
 		files           [][]string
 	)
 	for i, line := range sum {
@@ -177,6 +401,7 @@ func renderMarkdownNested(sum [][]string, data *[][]string) {
 		if line[0][0] == pkgMarker {
 			pkgName = line[0][1:]
 			pkgCov = line[1]
+			pkgLine = append([]string{pkgName}, line[1:]...)
 		} else {
 			files = append(files, line)
 		}
@@ -184,17 +409,33 @@ func renderMarkdownNested(sum [][]string, data *[][]string) {
 		// If we reached the end, or the next line is a different package, that
 		// means we're done with the current one, so render it.
 		if i == len(sum)-1 || (i+1 < len(sum) && sum[i+1][0][0] == pkgMarker) {
+			if len(files) == 0 {
+				// Packages without file details are rendered as-is.
+				*data = append(*data, []string{markdownName(pkgLine), pkgCov})
+				continue
+			}
 			var fileData bytes.Buffer
 			if err := tmpl.Execute(&fileData, files); err != nil {
 				panic(err)
 			}
-			pkgData := fmt.Sprintf(pkgDataTmpl, pkgName, fileData.String())
+			pkgData := fmt.Sprintf(pkgDataTmpl, markdownName(pkgLine), fileData.String())
 			*data = append(*data, []string{pkgData, pkgCov})
 			files = [][]string{}
 		}
 	}
 }
 
+// markdownName returns the name in the line formatted as code, and linked to
+// the URL in the third element of the line, if any.
+func markdownName(line []string) string {
+	name := fmt.Sprintf("`%s`", line[0])
+	if len(line) > 2 && line[2] != "" {
+		name = fmt.Sprintf("[%s](%s)", name, line[2])
+	}
+
+	return name
+}
+
 func renderTextNested(sum [][]string, data *[][]string) {
 	for _, line := range sum {
 		// HACK: Package lines are distinguished by a prefix marker. Otherwise
@@ -208,25 +449,17 @@ func renderTextNested(sum [][]string, data *[][]string) {
 	}
 }
 
-// FormatFromString parses s into a valid Format value.
-func FormatFromString(s string) Format {
-	switch Format(s) {
-	case Text:
-		return Text
-	case Markdown:
-		return Markdown
-	default:
-		return ""
-	}
+func generateBadgeURL(cov float64, lowerThreshold, upperThreshold float64) string {
+	return generateBadgeURLWithLabel("Total Coverage", cov, lowerThreshold, upperThreshold)
 }
 
-func generateBadgeURL(cov float64, lowerThreshold, upperThreshold float64) string {
-	color := "success"
-	if cov < lowerThreshold {
-		color = "critical"
-	} else if cov < upperThreshold {
-		color = "yellow"
-	}
+func generateBadgeURLWithLabel(label string, cov float64, lowerThreshold, upperThreshold float64) string {
+	color := badgeColorFor(Thresholds{Lower: lowerThreshold, Upper: upperThreshold}.Health(cov)).name
+
+	// Dashes and underscores are separators in the shields.io path, so they
+	// must be escaped by doubling them.
+	label = strings.NewReplacer("-", "--", "_", "__").Replace(label)
 
-	return fmt.Sprintf("https://img.shields.io/badge/Total%%20Coverage-%.2f%%25-%s?style=flat", cov, color)
+	return fmt.Sprintf("https://img.shields.io/badge/%s-%.2f%%25-%s?style=flat",
+		url.PathEscape(label), cov, color)
 }
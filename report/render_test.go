@@ -1,6 +1,7 @@
 package report
 
 import (
+	"regexp"
 	"testing"
 	"text/template"
 
@@ -133,15 +134,73 @@ func TestReportRender(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := report.Render(tt.format, tt.nestFiles, tt.filter, 70, 90, tt.trimPackagePrefix)
+			got, err := report.Render(tt.format, RenderOptions{
+				NestFiles:         tt.nestFiles,
+				Filter:            tt.filter,
+				Thresholds:        Thresholds{Lower: 70, Upper: 90},
+				TrimPackagePrefix: tt.trimPackagePrefix,
+			})
+			require.NoError(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 
+	t.Run("health_indicators", func(t *testing.T) {
+		t.Parallel()
+		opts := RenderOptions{
+			Thresholds:        Thresholds{Lower: 70, Upper: 90},
+			ThresholdRules:    ThresholdRules{NewThresholdRule("pkg2", Thresholds{Lower: 40, Upper: 50})},
+			TrimPackagePrefix: "path/",
+			HealthIndicators:  true,
+			NestFiles:         true,
+		}
+
+		mdOpts := opts
+		mdOpts.NestFiles = false
+		got, err := report.Render(Markdown, mdOpts)
+		require.NoError(t, err)
+		assert.Equal(t, "![Total Coverage](https://img.shields.io/badge/Total%20Coverage-84.90%25-yellow?style=flat)\n\n"+
+			"| Package         |  Coverage |\n"+
+			"| :------         |  -------: |\n"+
+			"| `pkg1`          | 🟡 75.12% |\n"+
+			"| `pkg1/file1.go` | 🔴 35.42% |\n"+
+			"| `pkg1/file2.go` | 🟢 97.47% |\n"+
+			"| `pkg2`          | 🟢 64.86% |\n"+
+			"| `pkg2/file3.go` | 🟡 47.81% |", got)
+
+		// Text rows are only colored if Color is set.
+		got, err = report.Render(Text, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "pkg1         75.12% \n"+
+			"    file1.go 35.42% \n"+
+			"    file2.go 97.47% \n"+
+			"pkg2         64.86% \n"+
+			"    file3.go 47.81% \n\n"+
+			"Total Coverage: 84.90%", got)
+
+		opts.Color = true
+		got, err = report.Render(Text, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "pkg1         \x1b[33m75.12%\x1b[0m \n"+
+			"    file1.go \x1b[31m35.42%\x1b[0m \n"+
+			"    file2.go \x1b[32m97.47%\x1b[0m \n"+
+			"pkg2         \x1b[32m64.86%\x1b[0m \n"+
+			"    file3.go \x1b[33m47.81%\x1b[0m \n\n"+
+			"\x1b[33mTotal Coverage: 84.90%\x1b[0m", got)
+	})
+
 	t.Run("empty", func(t *testing.T) {
 		t.Parallel()
 		r := &Report{}
-		assert.Equal(t, "", r.Render(Text, false, nil, 70, 90, ""))
+		got, err := r.Render(Text, RenderOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "", got)
+	})
+
+	t.Run("err/unknown_format", func(t *testing.T) {
+		t.Parallel()
+		_, err := report.Render("unknown", RenderOptions{})
+		assert.EqualError(t, err, "unsupported report format: unknown")
 	})
 }
 
@@ -318,3 +377,90 @@ func TestGenerateBadgeURL(t *testing.T) {
 		assert.Equal(t, tt.want, got)
 	}
 }
+
+func TestReportRenderLinks(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{Coverage: 0.5},
+		Packages: map[string]*Package{
+			"example.com/mod/pkg1": {
+				Stats: types.Stats{Coverage: 0.5},
+				Name:  "example.com/mod/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Stats:   types.Stats{Coverage: 0.5},
+						Name:    "file1.go",
+						Package: "example.com/mod/pkg1",
+						Blocks: []Block{
+							{FileBlock: types.FileBlock{Start: types.FileLocation{Line: 3}}, HitCount: 1},
+							{FileBlock: types.FileBlock{Start: types.FileLocation{Line: 12}}, HitCount: 0},
+						},
+					},
+				},
+			},
+		},
+		Metadata: Metadata{Repository: "org/repo", Commit: "abc123"},
+	}
+
+	opts := RenderOptions{
+		Thresholds:        Thresholds{Lower: 70, Upper: 90},
+		TrimPackagePrefix: "example.com/mod/",
+		LinkTemplate:      "https://git.example.com/{repo}/blob/{sha}/{path}#L{line}",
+		PathRemaps:        PathRemaps{{From: "example.com/mod/", To: ""}},
+	}
+
+	t.Run("md_nest", func(t *testing.T) {
+		t.Parallel()
+		opts := opts
+		opts.NestFiles = true
+		got, err := report.Render(Markdown, opts)
+		require.NoError(t, err)
+		assert.Contains(t, got, "| <details><summary>"+
+			"[`pkg1`](https://git.example.com/org/repo/blob/abc123/pkg1#L1)</summary>"+
+			"<table><tr><td>[`file1.go`](https://git.example.com/org/repo/blob/abc123/pkg1/file1.go#L12)</td>"+
+			"<td>50.00%</td></tr></table></details> |   50.00% |")
+	})
+
+	t.Run("md_nonest", func(t *testing.T) {
+		t.Parallel()
+		got, err := report.Render(Markdown, opts)
+		require.NoError(t, err)
+		assert.Contains(t, got,
+			"| [`pkg1`](https://git.example.com/org/repo/blob/abc123/pkg1#L1)                    |   50.00% |\n"+
+				"| [`pkg1/file1.go`](https://git.example.com/org/repo/blob/abc123/pkg1/file1.go#L12) |   50.00% |")
+	})
+
+	t.Run("txt", func(t *testing.T) {
+		t.Parallel()
+		got, err := report.Render(Text, opts)
+		require.NoError(t, err)
+		assert.NotContains(t, got, "https://")
+	})
+}
+
+func TestTotalCoverageLine(t *testing.T) {
+	t.Parallel()
+
+	rx := regexp.MustCompile(TotalCoverageRegex)
+	for cov, exp := range map[float64]string{
+		0:      "0.00",
+		0.4504: "45.04",
+		1:      "100.00",
+	} {
+		line := TotalCoverageLine(cov)
+		assert.Equal(t, "Total Coverage: "+exp+"%", line)
+		assert.Equal(t, []string{line, exp}, rx.FindStringSubmatch(line))
+	}
+
+	// The text format must include the line unmodified.
+	report := &Report{
+		Stats: types.Stats{Coverage: 0.4504},
+		Packages: map[string]*Package{
+			"pkg": {Name: "pkg", Files: map[string]*File{"file.go": {Name: "file.go", Package: "pkg"}}},
+		},
+	}
+	out, err := report.Render(Text, RenderOptions{})
+	require.NoError(t, err)
+	assert.Regexp(t, "(?m)^"+TotalCoverageRegex+"$", out)
+}
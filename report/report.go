@@ -2,6 +2,7 @@ package report
 
 import (
 	"path"
+	"sort"
 
 	"go.hackfix.me/fcov/types"
 )
@@ -18,6 +19,32 @@ type File struct {
 	types.Stats
 	Name    string
 	Package string
+	// Blocks are the code blocks of the file, sorted by their position.
+	Blocks []Block
+}
+
+// Block holds coverage information related to a block of code in a file.
+type Block struct {
+	types.FileBlock
+	NumStatements int
+	HitCount      int
+}
+
+// Covered returns true if the block was executed at least once.
+func (b Block) Covered() bool {
+	return b.HitCount > 0
+}
+
+// UncoveredBlocks returns the blocks of the file that were never executed.
+func (f File) UncoveredBlocks() []Block {
+	var blocks []Block
+	for _, b := range f.Blocks {
+		if !b.Covered() {
+			blocks = append(blocks, b)
+		}
+	}
+
+	return blocks
 }
 
 // AbsPath returns the absolute path of the file.
@@ -29,6 +56,7 @@ func (f File) AbsPath() string {
 type Report struct {
 	types.Stats
 	Packages map[string]*Package
+	Metadata Metadata
 }
 
 // Create a new report based on the provided coverage.
@@ -39,13 +67,20 @@ func Create(cov *types.Coverage) *Report {
 		var (
 			numStatements int
 			hitCount      int
+			blocks        = make([]Block, 0, len(fileBlocks))
 		)
-		for _, stat := range fileBlocks {
+		for fb, stat := range fileBlocks {
 			numStatements += stat.NumStatements
 			if stat.HitCount > 0 {
 				hitCount += stat.NumStatements
 			}
+			blocks = append(blocks, Block{
+				FileBlock: fb, NumStatements: stat.NumStatements, HitCount: stat.HitCount,
+			})
 		}
+		sort.Slice(blocks, func(i, j int) bool {
+			return blocks[i].FileBlock.Before(blocks[j].FileBlock)
+		})
 
 		var (
 			pkg    = path.Dir(filename)
@@ -74,6 +109,7 @@ func Create(cov *types.Coverage) *Report {
 
 		fileSum.NumStatements = numStatements
 		fileSum.HitCount = hitCount
+		fileSum.Blocks = blocks
 		if numStatements > 0 {
 			fileSum.Coverage = float64(hitCount) / float64(numStatements)
 		}
@@ -58,6 +58,12 @@ func TestCreate(t *testing.T) {
 	assert.Equal(t, 4, p1f1.NumStatements)
 	assert.Equal(t, 1, p1f1.HitCount)
 	assert.Equal(t, 0.25, p1f1.Coverage)
+	require.Len(t, p1f1.Blocks, 2)
+	assert.Equal(t, 16, p1f1.Blocks[0].Start.Line)
+	assert.True(t, p1f1.Blocks[0].Covered())
+	assert.Equal(t, 22, p1f1.Blocks[1].Start.Line)
+	assert.False(t, p1f1.Blocks[1].Covered())
+	assert.Equal(t, []Block{p1f1.Blocks[1]}, p1f1.UncoveredBlocks())
 
 	require.Contains(t, rep.Packages, "pkg2")
 	pkg2 := rep.Packages["pkg2"]
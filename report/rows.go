@@ -0,0 +1,68 @@
+package report
+
+import (
+	"sort"
+	"strings"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// Scopes of a StatsRow.
+const (
+	ScopeTotal   = "total"
+	ScopePackage = "package"
+	ScopeFile    = "file"
+)
+
+// StatsRow is the stats of the total coverage, a package, or a file.
+type StatsRow struct {
+	types.Stats
+	// Scope is one of ScopeTotal, ScopePackage or ScopeFile.
+	Scope string
+	// Package is the package path, with opts.TrimPackagePrefix removed. It's
+	// empty for the total.
+	Package string
+	// File is the file path, with opts.TrimPackagePrefix removed. It's only
+	// set for files.
+	File string
+}
+
+// StatsRows returns the stats of the total coverage, followed by the stats of
+// each package and its files, sorted by path. Packages and files excluded by
+// opts.Filter are omitted.
+func (s *Report) StatsRows(opts RenderOptions) []StatsRow {
+	rows := []StatsRow{{Stats: s.Stats, Scope: ScopeTotal}}
+
+	pkgNames := make([]string, 0, len(s.Packages))
+	for pkgName := range s.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkgName := range pkgNames {
+		pkg := s.Packages[pkgName]
+		pkgPath := strings.TrimPrefix(pkgName, opts.TrimPackagePrefix)
+		if opts.Filter == nil || !opts.Filter.MatchesPath(pkgName) {
+			rows = append(rows, StatsRow{Stats: pkg.Stats, Scope: ScopePackage, Package: pkgPath})
+		}
+
+		fnames := make([]string, 0, len(pkg.Files))
+		for fname := range pkg.Files {
+			fnames = append(fnames, fname)
+		}
+		sort.Strings(fnames)
+		for _, fname := range fnames {
+			file := pkg.Files[fname]
+			absPath := file.AbsPath()
+			if opts.Filter != nil && opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+			rows = append(rows, StatsRow{
+				Stats: file.Stats, Scope: ScopeFile, Package: pkgPath,
+				File: strings.TrimPrefix(absPath, opts.TrimPackagePrefix),
+			})
+		}
+	}
+
+	return rows
+}
@@ -0,0 +1,45 @@
+package report
+
+import (
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestStatsRows(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{NumStatements: 6, HitCount: 3, Coverage: 0.5},
+		Packages: map[string]*Package{
+			"path/pkg1": {
+				Stats: types.Stats{NumStatements: 4, HitCount: 2, Coverage: 0.5},
+				Name:  "path/pkg1",
+				Files: map[string]*File{
+					"b.go": {Stats: types.Stats{NumStatements: 2, HitCount: 2, Coverage: 1}, Name: "b.go", Package: "path/pkg1"},
+					"a.go": {Stats: types.Stats{NumStatements: 2}, Name: "a.go", Package: "path/pkg1"},
+				},
+			},
+			"path/pkg2": {
+				Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5},
+				Name:  "path/pkg2",
+				Files: map[string]*File{
+					"c.go": {Stats: types.Stats{NumStatements: 2, HitCount: 1, Coverage: 0.5}, Name: "c.go", Package: "path/pkg2"},
+				},
+			},
+		},
+	}
+
+	got := report.StatsRows(RenderOptions{
+		Filter:            gitignore.CompileIgnoreLines("*/pkg2", "*/b.go"),
+		TrimPackagePrefix: "path/",
+	})
+	assert.Equal(t, []StatsRow{
+		{Stats: types.Stats{NumStatements: 6, HitCount: 3, Coverage: 0.5}, Scope: ScopeTotal},
+		{Stats: types.Stats{NumStatements: 4, HitCount: 2, Coverage: 0.5}, Scope: ScopePackage, Package: "pkg1"},
+		{Stats: types.Stats{NumStatements: 2}, Scope: ScopeFile, Package: "pkg1", File: "pkg1/a.go"},
+	}, got)
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SARIF is the format that renders uncovered code as a SARIF 2.1.0 log, which
+// can be shown by code scanning dashboards and IDE SARIF viewers.
+const SARIF Format = "sarif"
+
+// SARIF rule IDs.
+const (
+	sarifRuleUncoveredBlock     = "uncovered-block"
+	sarifRuleBelowThresholdFile = "below-threshold-file"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// sarifRules are the rules reported by fcov. The result's ruleIndex refers to
+// the position in this list.
+func sarifRules() []sarifRule {
+	return []sarifRule{
+		{
+			ID:                   sarifRuleUncoveredBlock,
+			Name:                 "UncoveredBlock",
+			ShortDescription:     sarifMessage{Text: "Code block not covered by tests."},
+			DefaultConfiguration: sarifRuleDefaults{Level: "note"},
+		},
+		{
+			ID:                   sarifRuleBelowThresholdFile,
+			Name:                 "BelowThresholdFile",
+			ShortDescription:     sarifMessage{Text: "File coverage below the lower threshold."},
+			DefaultConfiguration: sarifRuleDefaults{Level: "warning"},
+		},
+	}
+}
+
+// sarifRenderer renders the report as a SARIF log. Each uncovered block becomes
+// a result of the uncovered-block rule, and each file with coverage below the
+// lower threshold a result of the below-threshold-file rule. Go coverage
+// profiles don't contain function data, so results are always reported per
+// block. File paths are converted using opts.PathRemaps, which should be used
+// to make them relative to the repository root.
+type sarifRenderer struct{}
+
+func (sarifRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "fcov",
+			Version:        s.Metadata.Version,
+			InformationURI: "https://github.com/hackfixme/fcov",
+			Rules:          sarifRules(),
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, b := range s.UncoveredBlocks(opts) {
+		run.Results = append(run.Results, sarifResult{
+			RuleID: sarifRuleUncoveredBlock, RuleIndex: 0, Level: "note",
+			Message: sarifMessage{Text: fmt.Sprintf(
+				"%d %s not covered by tests.", b.NumStatements,
+				pluralize(b.NumStatements, "statement is", "statements are"))},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: b.Path},
+				Region: &sarifRegion{
+					StartLine: b.Start.Line, StartColumn: b.Start.Col,
+					EndLine: b.End.Line, EndColumn: b.End.Col,
+				},
+			}}},
+		})
+	}
+
+	run.Results = append(run.Results, s.sarifBelowThreshold(opts)...)
+
+	data, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed encoding SARIF log: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// sarifBelowThreshold returns the results of files with coverage below the
+// lower threshold, sorted by path.
+func (s *Report) sarifBelowThreshold(opts RenderOptions) []sarifResult {
+	var results []sarifResult
+	for _, pkg := range s.Packages {
+		for _, file := range pkg.Files {
+			absPath := file.AbsPath()
+			if opts.Filter != nil && opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+			pct := file.Coverage * 100
+			th := opts.ThresholdsFor(absPath)
+			if th.Health(pct) != HealthCritical {
+				continue
+			}
+			results = append(results, sarifResult{
+				RuleID: sarifRuleBelowThresholdFile, RuleIndex: 1, Level: "warning",
+				Message: sarifMessage{Text: fmt.Sprintf(
+					"File coverage is %.2f%%, below the lower threshold of %.2f%%.",
+					pct, th.Lower)},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: opts.PathRemaps.Apply(absPath)},
+					// Some consumers, like GitHub code scanning, require a region,
+					// so point to the start of the file.
+					Region: &sarifRegion{StartLine: 1},
+				}}},
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Locations[0].PhysicalLocation.ArtifactLocation.URI <
+			results[j].Locations[0].PhysicalLocation.ArtifactLocation.URI
+	})
+
+	return results
+}
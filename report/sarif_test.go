@@ -0,0 +1,129 @@
+package report
+
+import (
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestSARIFRender(t *testing.T) {
+	t.Parallel()
+
+	block := func(startLine, startCol, endLine, endCol, numStmt, hits int) Block {
+		return Block{
+			FileBlock: types.FileBlock{
+				Start: types.FileLocation{Line: startLine, Col: startCol},
+				End:   types.FileLocation{Line: endLine, Col: endCol},
+			},
+			NumStatements: numStmt, HitCount: hits,
+		}
+	}
+
+	report := &Report{
+		Packages: map[string]*Package{
+			"example.com/mod/pkg1": {
+				Name: "example.com/mod/pkg1",
+				Files: map[string]*File{
+					"file1.go": {
+						Stats: types.Stats{NumStatements: 4, HitCount: 1, Coverage: 0.25},
+						Name:  "file1.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(3, 10, 5, 2, 1, 1), block(7, 20, 9, 3, 3, 0)},
+					},
+					"file2.go": {
+						Stats: types.Stats{NumStatements: 4, HitCount: 3, Coverage: 0.75},
+						Name:  "file2.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(3, 10, 5, 2, 3, 2), block(6, 1, 6, 15, 1, 0)},
+					},
+				},
+			},
+			"example.com/mod/pkg2": {
+				Name: "example.com/mod/pkg2",
+				Files: map[string]*File{
+					"file3.go": {
+						Stats: types.Stats{NumStatements: 1, Coverage: 0},
+						Name:  "file3.go", Package: "example.com/mod/pkg2",
+						Blocks: []Block{block(1, 1, 2, 2, 1, 0)},
+					},
+				},
+			},
+		},
+		Metadata: Metadata{Version: "v1.2.3"},
+	}
+
+	got, err := report.Render(SARIF, RenderOptions{
+		Filter:     gitignore.CompileIgnoreLines("*/pkg2"),
+		Thresholds: Thresholds{Lower: 50, Upper: 75},
+		PathRemaps: PathRemaps{{From: "example.com/mod/", To: ""}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {
+      "driver": {
+        "name": "fcov",
+        "version": "v1.2.3",
+        "informationUri": "https://github.com/hackfixme/fcov",
+        "rules": [
+          {
+            "id": "uncovered-block",
+            "name": "UncoveredBlock",
+            "shortDescription": {"text": "Code block not covered by tests."},
+            "defaultConfiguration": {"level": "note"}
+          },
+          {
+            "id": "below-threshold-file",
+            "name": "BelowThresholdFile",
+            "shortDescription": {"text": "File coverage below the lower threshold."},
+            "defaultConfiguration": {"level": "warning"}
+          }
+        ]
+      }
+    },
+    "results": [
+      {
+        "ruleId": "uncovered-block",
+        "ruleIndex": 0,
+        "level": "note",
+        "message": {"text": "3 statements are not covered by tests."},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "pkg1/file1.go"},
+          "region": {"startLine": 7, "startColumn": 20, "endLine": 9, "endColumn": 3}
+        }}]
+      },
+      {
+        "ruleId": "uncovered-block",
+        "ruleIndex": 0,
+        "level": "note",
+        "message": {"text": "1 statement is not covered by tests."},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "pkg1/file2.go"},
+          "region": {"startLine": 6, "startColumn": 1, "endLine": 6, "endColumn": 15}
+        }}]
+      },
+      {
+        "ruleId": "below-threshold-file",
+        "ruleIndex": 1,
+        "level": "warning",
+        "message": {"text": "File coverage is 25.00%, below the lower threshold of 50.00%."},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "pkg1/file1.go"},
+          "region": {"startLine": 1}
+        }}]
+      }
+    ]
+  }]
+}`, got)
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		got, err := (&Report{}).Render(SARIF, RenderOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, got, `"results": []`)
+	})
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// SonarQube is the format that renders the report as a SonarQube generic
+// test coverage XML document.
+// See https://docs.sonarsource.com/sonarqube/latest/analyzing-source-code/test-coverage/generic-test-data/
+const SonarQube Format = "sonarqube"
+
+type sonarCoverage struct {
+	XMLName xml.Name    `xml:"coverage"`
+	Version int         `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path  string             `xml:"path,attr"`
+	Lines []sonarLineToCover `xml:"lineToCover"`
+}
+
+type sonarLineToCover struct {
+	LineNumber int  `xml:"lineNumber,attr"`
+	Covered    bool `xml:"covered,attr"`
+}
+
+// sonarQubeRenderer renders the report as a SonarQube generic coverage XML
+// document, with the line coverage computed by lineHits. File paths are
+// converted using opts.PathRemaps, and must be relative to the SonarQube
+// project root.
+type sonarQubeRenderer struct{}
+
+func (sonarQubeRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	out := sonarCoverage{Version: 1}
+	for _, pkg := range s.Packages {
+		for _, file := range pkg.Files {
+			absPath := file.AbsPath()
+			if opts.Filter != nil && opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+
+			sfile := sonarFile{Path: opts.PathRemaps.Apply(absPath)}
+			for _, lh := range lineHits(file.Blocks) {
+				sfile.Lines = append(sfile.Lines, sonarLineToCover{
+					LineNumber: lh.Line, Covered: lh.Hits > 0,
+				})
+			}
+			out.Files = append(out.Files, sfile)
+		}
+	}
+	sort.Slice(out.Files, func(i, j int) bool { return out.Files[i].Path < out.Files[j].Path })
+
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed encoding SonarQube report: %w", err)
+	}
+
+	return xml.Header + string(data), nil
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestSonarQubeRender(t *testing.T) {
+	t.Parallel()
+
+	block := func(startLine, endLine, hits int) Block {
+		return Block{
+			FileBlock: types.FileBlock{
+				Start: types.FileLocation{Line: startLine, Col: 1},
+				End:   types.FileLocation{Line: endLine, Col: 2},
+			},
+			NumStatements: 1, HitCount: hits,
+		}
+	}
+
+	report := &Report{
+		Packages: map[string]*Package{
+			"example.com/mod/pkg1": {
+				Name: "example.com/mod/pkg1",
+				Files: map[string]*File{
+					"file2.go": {
+						Name: "file2.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(3, 4, 2), block(4, 5, 0)},
+					},
+					"file1.go": {
+						Name: "file1.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(1, 1, 0)},
+					},
+				},
+			},
+			"example.com/mod/pkg2": {
+				Name: "example.com/mod/pkg2",
+				Files: map[string]*File{
+					"file3.go": {
+						Name: "file3.go", Package: "example.com/mod/pkg2",
+						Blocks: []Block{block(1, 2, 3)},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := report.Render(SonarQube, RenderOptions{
+		Filter:     gitignore.CompileIgnoreLines("*/pkg2"),
+		PathRemaps: PathRemaps{{From: "example.com/mod/", To: "src/"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<coverage version="1">
+  <file path="src/pkg1/file1.go">
+    <lineToCover lineNumber="1" covered="false"></lineToCover>
+  </file>
+  <file path="src/pkg1/file2.go">
+    <lineToCover lineNumber="3" covered="true"></lineToCover>
+    <lineToCover lineNumber="4" covered="true"></lineToCover>
+    <lineToCover lineNumber="5" covered="false"></lineToCover>
+  </file>
+</coverage>`, got)
+}
@@ -0,0 +1,291 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"go.hackfix.me/fcov/types"
+)
+
+// Template is the format that renders the report with a user-provided
+// text/template, set in RenderOptions.Template.
+const Template Format = "tmpl"
+
+// TemplateData is the data model that user-provided templates are executed
+// against. Packages and files are sorted by name, and exclude any paths
+// matched by RenderOptions.Filter.
+type TemplateData struct {
+	// Total holds the global coverage statistics. Note that these are
+	// unaffected by the output filter.
+	Total types.Stats
+	// Packages holds the packages that are part of the output.
+	Packages []TemplatePackage
+	// Files holds all files that are part of the output, across all packages.
+	Files      []TemplateFile
+	Thresholds Thresholds
+	// Health is the health of the total coverage: "critical", "warning" or
+	// "good".
+	Health string
+	// Baseline holds the global coverage statistics of a previous report, set
+	// in RenderOptions.Baseline. It's nil if no baseline is set.
+	Baseline *types.Stats
+	// Delta is the difference between the total and the baseline coverage, in
+	// percentage points. It's 0 if no baseline is set.
+	Delta    float64
+	Metadata Metadata
+}
+
+// TemplatePackage holds coverage information related to a package.
+type TemplatePackage struct {
+	types.Stats
+	// Name is the package path with RenderOptions.TrimPackagePrefix removed.
+	Name string
+	// Path is the full package path.
+	Path string
+	// URL is the link to the package source, if a link template is set.
+	URL string
+	// Health is the health of the package coverage, using the thresholds of
+	// the matching threshold rule: "critical", "warning" or "good".
+	Health string
+	Files  []TemplateFile
+}
+
+// TemplateFile holds coverage information related to a file.
+type TemplateFile struct {
+	types.Stats
+	// Name is the base name of the file.
+	Name string
+	// Path is the full file path, including the package path.
+	Path string
+	// Package is the package path with RenderOptions.TrimPackagePrefix removed.
+	Package string
+	// URL is the link to the first uncovered line of the file source, if a
+	// link template is set.
+	URL string
+	// Health is the health of the file coverage, using the thresholds of the
+	// matching threshold rule: "critical", "warning" or "good".
+	Health string
+}
+
+// templateRenderer renders the report by executing the template in
+// RenderOptions.Template.
+type templateRenderer struct{}
+
+func (templateRenderer) Render(s *Report, opts RenderOptions) (string, error) {
+	if opts.Template == "" {
+		return "", fmt.Errorf("no template provided")
+	}
+
+	tmpl, err := template.New("report").
+		Funcs(templateFuncs(opts)).
+		Parse(opts.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing template: %w", err)
+	}
+
+	buf := &strings.Builder{}
+	if err = tmpl.Execute(buf, s.templateData(opts)); err != nil {
+		return "", fmt.Errorf("failed executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// templateData converts the report into the template data model.
+func (s *Report) templateData(opts RenderOptions) TemplateData {
+	data := TemplateData{
+		Total:      s.Stats,
+		Packages:   []TemplatePackage{},
+		Files:      []TemplateFile{},
+		Thresholds: opts.Thresholds,
+		Health:     opts.Thresholds.Health(s.Coverage * 100).String(),
+		Baseline:   opts.Baseline,
+		Metadata:   s.Metadata,
+	}
+	if opts.Baseline != nil {
+		data.Delta = (s.Coverage - opts.Baseline.Coverage) * 100
+	}
+
+	pkgNames := make([]string, 0, len(s.Packages))
+	for pkgName := range s.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkgName := range pkgNames {
+		pkg := s.Packages[pkgName]
+		tpkg := TemplatePackage{
+			Stats:  pkg.Stats,
+			Name:   strings.TrimPrefix(pkgName, opts.TrimPackagePrefix),
+			Path:   pkgName,
+			URL:    s.packageURL(opts, pkgName),
+			Health: opts.ThresholdsFor(pkgName).Health(pkg.Coverage * 100).String(),
+			Files:  []TemplateFile{},
+		}
+
+		fnames := make([]string, 0, len(pkg.Files))
+		for fname := range pkg.Files {
+			fnames = append(fnames, fname)
+		}
+		sort.Strings(fnames)
+
+		for _, fname := range fnames {
+			file := pkg.Files[fname]
+			absPath := file.AbsPath()
+			if opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+			tpkg.Files = append(tpkg.Files, TemplateFile{
+				Stats: file.Stats, Name: fname, Path: absPath, Package: tpkg.Name,
+				URL:    s.fileURL(opts, file),
+				Health: opts.ThresholdsFor(absPath).Health(file.Coverage * 100).String(),
+			})
+		}
+
+		if opts.Filter.MatchesPath(pkgName) && len(tpkg.Files) == 0 {
+			continue
+		}
+		data.Packages = append(data.Packages, tpkg)
+		data.Files = append(data.Files, tpkg.Files...)
+	}
+
+	return data
+}
+
+// templateFuncs returns the helper functions available to user templates.
+func templateFuncs(opts RenderOptions) template.FuncMap {
+	return template.FuncMap{
+		// percent formats a coverage ratio as a percentage, e.g. 0.849 -> 84.90%.
+		"percent": func(cov float64) string {
+			return fmt.Sprintf("%.2f%%", cov*100)
+		},
+		// health returns "critical", "warning" or "good" depending on where
+		// the coverage ratio falls within the thresholds. If a path precedes
+		// the ratio, the thresholds of the matching threshold rule are used.
+		"health": func(args ...any) (string, error) {
+			th, cov, err := thresholdsArgs(opts, "health", args)
+			if err != nil {
+				return "", err
+			}
+			return th.Health(cov * 100).String(), nil
+		},
+		// badge returns the URL of a badge with the given label and coverage
+		// ratio, colored according to the thresholds. If a path precedes the
+		// ratio, the thresholds of the matching threshold rule are used.
+		"badge": func(label string, args ...any) (string, error) {
+			th, cov, err := thresholdsArgs(opts, "badge", args)
+			if err != nil {
+				return "", err
+			}
+			return generateBadgeURLWithLabel(label, cov*100, th.Lower, th.Upper), nil
+		},
+		// json returns the value encoded as JSON, e.g. to safely include
+		// strings in JSON payloads.
+		"json":           toJSON,
+		"sortByName":     sortByName,
+		"sortByCoverage": sortByCoverage,
+		"reverse":        reverse,
+		"limit":          limit,
+	}
+}
+
+// thresholdsArgs parses the '[<path>] <ratio>' arguments of the template
+// function fn. It returns the thresholds of the path, or the global thresholds
+// if no path is provided, and the coverage ratio.
+func thresholdsArgs(opts RenderOptions, fn string, args []any) (Thresholds, float64, error) {
+	th := opts.Thresholds
+	switch len(args) {
+	case 1:
+	case 2:
+		path, ok := args[0].(string)
+		if !ok {
+			return th, 0, fmt.Errorf("%s: path must be a string, got %T", fn, args[0])
+		}
+		th = opts.ThresholdsFor(path)
+	default:
+		return th, 0, fmt.Errorf("%s: expected [<path>] <ratio> arguments, got %d", fn, len(args))
+	}
+
+	switch v := reflect.ValueOf(args[len(args)-1]); {
+	case v.CanFloat():
+		return th, v.Float(), nil
+	case v.CanInt():
+		return th, float64(v.Int()), nil
+	default:
+		return th, 0, fmt.Errorf("%s: ratio must be a number, got %T", fn, args[len(args)-1])
+	}
+}
+
+// toJSON returns the value encoded as JSON.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// sortByName returns a copy of the packages or files sorted by name.
+func sortByName(items any) (any, error) {
+	switch v := items.(type) {
+	case []TemplatePackage:
+		v = append([]TemplatePackage(nil), v...)
+		sort.SliceStable(v, func(i, j int) bool { return v[i].Name < v[j].Name })
+		return v, nil
+	case []TemplateFile:
+		v = append([]TemplateFile(nil), v...)
+		sort.SliceStable(v, func(i, j int) bool { return v[i].Path < v[j].Path })
+		return v, nil
+	default:
+		return nil, fmt.Errorf("sortByName: unsupported type %T", items)
+	}
+}
+
+// sortByCoverage returns a copy of the packages or files sorted by coverage,
+// in ascending order.
+func sortByCoverage(items any) (any, error) {
+	switch v := items.(type) {
+	case []TemplatePackage:
+		v = append([]TemplatePackage(nil), v...)
+		sort.SliceStable(v, func(i, j int) bool { return v[i].Coverage < v[j].Coverage })
+		return v, nil
+	case []TemplateFile:
+		v = append([]TemplateFile(nil), v...)
+		sort.SliceStable(v, func(i, j int) bool { return v[i].Coverage < v[j].Coverage })
+		return v, nil
+	default:
+		return nil, fmt.Errorf("sortByCoverage: unsupported type %T", items)
+	}
+}
+
+// reverse returns a copy of the slice in reverse order.
+func reverse(items any) (any, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("reverse: unsupported type %T", items)
+	}
+	out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	for i := range v.Len() {
+		out.Index(v.Len() - 1 - i).Set(v.Index(i))
+	}
+
+	return out.Interface(), nil
+}
+
+// limit returns at most the first n elements of the slice.
+func limit(n int, items any) (any, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("limit: unsupported type %T", items)
+	}
+	if n < 0 || n >= v.Len() {
+		return items, nil
+	}
+
+	return v.Slice(0, n).Interface(), nil
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestTemplateRender(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Stats: types.Stats{NumStatements: 10, HitCount: 6, Coverage: 0.6},
+		Packages: map[string]*Package{
+			"path/pkg1": {
+				Stats: types.Stats{Coverage: 0.8},
+				Name:  "path/pkg1",
+				Files: map[string]*File{
+					"file1.go": {Stats: types.Stats{Coverage: 0.7}, Name: "file1.go", Package: "path/pkg1"},
+					"file2.go": {Stats: types.Stats{Coverage: 0.9}, Name: "file2.go", Package: "path/pkg1"},
+				},
+			},
+			"path/pkg2": {
+				Stats: types.Stats{Coverage: 0.4},
+				Name:  "path/pkg2",
+				Files: map[string]*File{
+					"file3.go": {Stats: types.Stats{Coverage: 0.4}, Name: "file3.go", Package: "path/pkg2"},
+				},
+			},
+		},
+		Metadata: Metadata{
+			Version:   "v1.2.3",
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		filter   *gitignore.GitIgnore
+		baseline *types.Stats
+		want     string
+		expErr   string
+	}{
+		{
+			name: "ok/total",
+			tmpl: `{{ percent .Total.Coverage }} {{ .Total.HitCount }}/{{ .Total.NumStatements }} ` +
+				`{{ health .Total.Coverage }} {{ .Metadata.Version }} {{ .Metadata.Timestamp.Format "2006-01-02" }}`,
+			want: "60.00% 6/10 warning v1.2.3 2025-01-02",
+		},
+		{
+			name: "ok/packages",
+			tmpl: `{{ range .Packages }}{{ .Name }}:{{ percent .Coverage }}` +
+				`{{ range .Files }},{{ .Name }}{{ end }};{{ end }}`,
+			want: "pkg1:80.00%,file1.go,file2.go;pkg2:40.00%,file3.go;",
+		},
+		{
+			name:   "ok/filter",
+			tmpl:   `{{ range .Files }}{{ .Path }};{{ end }}`,
+			filter: gitignore.CompileIgnoreLines("*/pkg1"),
+			want:   "path/pkg2/file3.go;",
+		},
+		{
+			name: "ok/sort_limit",
+			tmpl: `{{ range limit 2 (sortByCoverage .Files) }}{{ .Name }};{{ end }}` +
+				`{{ range reverse (sortByName .Packages) }}{{ .Path }};{{ end }}`,
+			want: "file3.go;file1.go;path/pkg2;path/pkg1;",
+		},
+		{
+			name: "ok/badge",
+			tmpl: `{{ badge "My-Label" .Total.Coverage }}`,
+			want: "https://img.shields.io/badge/My--Label-60.00%25-yellow?style=flat",
+		},
+		{
+			name: "ok/threshold_rules",
+			tmpl: `{{ range .Packages }}{{ .Name }}:{{ health .Coverage }},{{ health .Path .Coverage }},` +
+				`{{ .Health }};{{ end }}{{ badge "pkg2" "path/pkg2" 0.4 }}`,
+			want: "pkg1:good,critical,critical;pkg2:critical,good,good;" +
+				"https://img.shields.io/badge/pkg2-40.00%25-success?style=flat",
+		},
+		{
+			name:     "ok/baseline",
+			tmpl:     `{{ .Health }} {{ printf "%+.2f" .Delta }} {{ percent .Baseline.Coverage }}`,
+			baseline: &types.Stats{Coverage: 0.625},
+			want:     "warning -2.50 62.50%",
+		},
+		{
+			name: "ok/no_baseline",
+			tmpl: `{{ if .Baseline }}delta{{ else }}none {{ .Delta }}{{ end }}`,
+			want: "none 0",
+		},
+		{
+			name: "ok/json",
+			tmpl: `{"text": {{ json (printf "%s \"quoted\"" .Metadata.Version) }}, "total": {{ json .Total }}}`,
+			want: `{"text": "v1.2.3 \"quoted\"", "total": {"NumStatements":10,"HitCount":6,"Coverage":0.6}}`,
+		},
+		{
+			name:   "err/empty",
+			tmpl:   "",
+			expErr: "no template provided",
+		},
+		{
+			name:   "err/parse",
+			tmpl:   "{{ .Total",
+			expErr: "failed parsing template: template: report:1: unclosed action",
+		},
+		{
+			name:   "err/health_args",
+			tmpl:   `{{ health "path/pkg1" .Total.Coverage 1 }}`,
+			expErr: "failed executing template: template: report:1:3: executing \"report\" at <health \"path/pkg1\" .Total.Coverage 1>: error calling health: health: expected [<path>] <ratio> arguments, got 3",
+		},
+		{
+			name:   "err/health_ratio",
+			tmpl:   `{{ health "path/pkg1" }}`,
+			expErr: "failed executing template: template: report:1:3: executing \"report\" at <health \"path/pkg1\">: error calling health: health: ratio must be a number, got string",
+		},
+		{
+			name:   "err/exec",
+			tmpl:   "{{ sortByName .Total }}",
+			expErr: "failed executing template: template: report:1:3: executing \"report\" at <sortByName .Total>: error calling sortByName: sortByName: unsupported type types.Stats",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := report.Render(Template, RenderOptions{
+				Filter:     tt.filter,
+				Thresholds: Thresholds{Lower: 50, Upper: 75},
+				ThresholdRules: ThresholdRules{
+					NewThresholdRule("pkg1", Thresholds{Lower: 85, Upper: 95}),
+					NewThresholdRule("pkg2", Thresholds{Lower: 20, Upper: 30}),
+				},
+				TrimPackagePrefix: "path/",
+				Template:          tt.tmpl,
+				Baseline:          tt.baseline,
+			})
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
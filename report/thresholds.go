@@ -0,0 +1,168 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// Thresholds are the lower and upper coverage percentages used to determine
+// the health of a package, file or the entire report.
+type Thresholds struct {
+	Lower, Upper float64
+}
+
+// Health is the coverage health level determined by Thresholds.
+type Health int
+
+// Health levels, from worst to best.
+const (
+	HealthCritical Health = iota
+	HealthWarning
+	HealthGood
+)
+
+// String returns the name of the health level.
+func (h Health) String() string {
+	switch h {
+	case HealthCritical:
+		return "critical"
+	case HealthWarning:
+		return "warning"
+	default:
+		return "good"
+	}
+}
+
+// icon returns the Markdown indicator of the health level.
+func (h Health) icon() string {
+	switch h {
+	case HealthCritical:
+		return "🔴"
+	case HealthWarning:
+		return "🟡"
+	default:
+		return "🟢"
+	}
+}
+
+// ansiReset is the ANSI escape sequence that resets the text color.
+const ansiReset = "\x1b[0m"
+
+// ansiColor returns the ANSI escape sequence of the text color of the health
+// level.
+func (h Health) ansiColor() string {
+	switch h {
+	case HealthCritical:
+		return "\x1b[31m"
+	case HealthWarning:
+		return "\x1b[33m"
+	default:
+		return "\x1b[32m"
+	}
+}
+
+// Health returns the health level of the coverage percentage pct. Coverage
+// below the lower threshold is critical, below the upper threshold is a
+// warning, and anything else is good.
+func (t Thresholds) Health(pct float64) Health {
+	switch {
+	case pct < t.Lower:
+		return HealthCritical
+	case pct < t.Upper:
+		return HealthWarning
+	default:
+		return HealthGood
+	}
+}
+
+// ParseThresholds parses thresholds in the form of "<lower>,<upper>".
+func ParseThresholds(s string) (Thresholds, error) {
+	lower, upper, ok := strings.Cut(s, ",")
+	if !ok || strings.Contains(upper, ",") {
+		return Thresholds{}, fmt.Errorf("invalid thresholds value: %s", s)
+	}
+
+	var (
+		t   Thresholds
+		err error
+	)
+	if t.Lower, err = strconv.ParseFloat(lower, 64); err != nil {
+		return Thresholds{}, fmt.Errorf("invalid lower threshold '%s': %w", lower, err)
+	}
+	if t.Upper, err = strconv.ParseFloat(upper, 64); err != nil {
+		return Thresholds{}, fmt.Errorf("invalid upper threshold '%s': %w", upper, err)
+	}
+
+	return t, nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for
+// Thresholds, using ParseThresholds.
+func (t *Thresholds) UnmarshalText(text []byte) error {
+	th, err := ParseThresholds(string(text))
+	if err != nil {
+		return err
+	}
+	*t = th
+
+	return nil
+}
+
+// ThresholdRule applies its thresholds to the package and file paths that
+// match a gitignore-style glob pattern. It must be created with
+// NewThresholdRule or UnmarshalText, which compile the pattern.
+type ThresholdRule struct {
+	Pattern string
+	Thresholds
+	matcher *gitignore.GitIgnore
+}
+
+// UnmarshalText parses a threshold rule in the form of
+// "<pattern>=<lower>,<upper>".
+func (tr *ThresholdRule) UnmarshalText(text []byte) error {
+	pattern, thresholds, ok := strings.Cut(string(text), "=")
+	if !ok || pattern == "" {
+		return fmt.Errorf("invalid threshold rule value: %s", text)
+	}
+	th, err := ParseThresholds(thresholds)
+	if err != nil {
+		return fmt.Errorf("invalid threshold rule '%s': %w", pattern, err)
+	}
+	*tr = NewThresholdRule(pattern, th)
+
+	return nil
+}
+
+// NewThresholdRule returns a rule that applies the thresholds to paths that
+// match the pattern.
+func NewThresholdRule(pattern string, th Thresholds) ThresholdRule {
+	return ThresholdRule{
+		Pattern: pattern, Thresholds: th,
+		matcher: gitignore.CompileIgnoreLines(pattern),
+	}
+}
+
+// ThresholdRules is an ordered list of threshold rules.
+type ThresholdRules []ThresholdRule
+
+// For returns the thresholds of the first rule whose pattern matches any of
+// the paths, or def if no rule matches. Multiple paths can be provided to
+// match different forms of the same path, e.g. the full package path and the
+// path relative to the repository root.
+func (trs ThresholdRules) For(def Thresholds, paths ...string) Thresholds {
+	for _, tr := range trs {
+		if tr.matcher == nil {
+			continue
+		}
+		for _, p := range paths {
+			if tr.matcher.MatchesPath(p) {
+				return tr.Thresholds
+			}
+		}
+	}
+
+	return def
+}
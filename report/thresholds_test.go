@@ -0,0 +1,97 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThresholds(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		in     string
+		exp    Thresholds
+		expErr string
+	}{
+		{in: "50,75", exp: Thresholds{Lower: 50, Upper: 75}},
+		{in: "0,12.5", exp: Thresholds{Lower: 0, Upper: 12.5}},
+		{in: "50", expErr: "invalid thresholds value: 50"},
+		{in: "50,75,90", expErr: "invalid thresholds value: 50,75,90"},
+		{in: "a,75", expErr: `invalid lower threshold 'a': strconv.ParseFloat: parsing "a": invalid syntax`},
+		{in: "50,", expErr: `invalid upper threshold '': strconv.ParseFloat: parsing "": invalid syntax`},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseThresholds(tt.in)
+			var unmarshaled Thresholds
+			errText := unmarshaled.UnmarshalText([]byte(tt.in))
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.EqualError(t, errText, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, errText)
+			assert.Equal(t, tt.exp, got)
+			assert.Equal(t, tt.exp, unmarshaled)
+		})
+	}
+}
+
+func TestThresholdRuleUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var tr ThresholdRule
+	require.NoError(t, tr.UnmarshalText([]byte("internal/core/**=80,90")))
+	assert.Equal(t, "internal/core/**", tr.Pattern)
+	assert.Equal(t, Thresholds{Lower: 80, Upper: 90}, tr.Thresholds)
+
+	assert.EqualError(t, tr.UnmarshalText([]byte("80,90")), "invalid threshold rule value: 80,90")
+	assert.EqualError(t, tr.UnmarshalText([]byte("cmd/**=80")),
+		"invalid threshold rule 'cmd/**': invalid thresholds value: 80")
+}
+
+func TestThresholdRulesFor(t *testing.T) {
+	t.Parallel()
+
+	def := Thresholds{Lower: 50, Upper: 75}
+	core := Thresholds{Lower: 80, Upper: 90}
+	cmd := Thresholds{Lower: 10, Upper: 30}
+	rules := ThresholdRules{
+		// Rules without a compiled pattern, e.g. created as struct literals,
+		// never match.
+		{Pattern: "other/**", Thresholds: cmd},
+		NewThresholdRule("internal/core/**", core),
+		NewThresholdRule("cmd/**", cmd),
+		NewThresholdRule("internal/**", def),
+		NewThresholdRule("*_gen.go", Thresholds{}),
+	}
+
+	testCases := []struct {
+		paths []string
+		exp   Thresholds
+	}{
+		{paths: []string{"internal/core/parse"}, exp: core},
+		{paths: []string{"internal/core/parse/parse.go"}, exp: core},
+		{paths: []string{"cmd/fcov/main.go"}, exp: cmd},
+		{paths: []string{"internal/other"}, exp: def},
+		{paths: []string{"pkg/types_gen.go"}, exp: Thresholds{}},
+		{paths: []string{"example.com/mod/cmd/fcov", "cmd/fcov"}, exp: cmd},
+		{paths: []string{"pkg"}, exp: def},
+		{paths: []string{"other/pkg"}, exp: def},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.paths[0], func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.exp, rules.For(def, tt.paths...))
+		})
+	}
+}
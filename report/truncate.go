@@ -0,0 +1,85 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// truncateMarkdown progressively reduces the detail of the Markdown output
+// until it fits within opts.MaxSize characters. First it omits the files of
+// fully covered packages, then the files of all packages, and finally it only
+// shows the packages with the lowest coverage. A note is appended to the
+// output explaining what was omitted.
+func (s *Report) truncateMarkdown(groups []rowGroup, opts RenderOptions) string {
+	fits := func(out string) bool {
+		return utf8.RuneCountInString(out) <= opts.MaxSize
+	}
+
+	var numCovered int
+	reduced := make([]rowGroup, len(groups))
+	for i, g := range groups {
+		if g.pkg.HitCount == g.pkg.NumStatements && len(g.files) > 0 {
+			g = collapseGroup(g)
+			numCovered++
+		}
+		reduced[i] = g
+	}
+	if numCovered > 0 {
+		note := fmt.Sprintf("File details were omitted for %d fully covered %s "+
+			"to keep the report within %d characters.",
+			numCovered, pluralize(numCovered, "package", "packages"), opts.MaxSize)
+		if out := s.renderMarkdownGroups(reduced, opts, note); fits(out) {
+			return out
+		}
+	}
+
+	for i, g := range reduced {
+		reduced[i] = collapseGroup(g)
+	}
+	note := fmt.Sprintf("File details were omitted for all packages "+
+		"to keep the report within %d characters.", opts.MaxSize)
+	if out := s.renderMarkdownGroups(reduced, opts, note); fits(out) {
+		return out
+	}
+
+	// Show only the packages with the lowest coverage, finding the largest
+	// amount that fits.
+	sort.SliceStable(reduced, func(i, j int) bool {
+		return reduced[i].pkg.Coverage < reduced[j].pkg.Coverage
+	})
+	worstNote := func(n int) string {
+		if n == 0 {
+			return fmt.Sprintf("All %d packages were omitted to keep the report "+
+				"within %d characters.", len(reduced), opts.MaxSize)
+		}
+		return fmt.Sprintf("Only the %d %s with the lowest coverage %s shown, "+
+			"and %d %s omitted, to keep the report within %d characters.",
+			n, pluralize(n, "package", "packages"), pluralize(n, "is", "are"),
+			len(reduced)-n, pluralize(len(reduced)-n, "was", "were"), opts.MaxSize)
+	}
+	n := sort.Search(len(reduced), func(n int) bool {
+		// Search for the first amount that doesn't fit.
+		return !fits(s.renderMarkdownGroups(reduced[:n+1], opts, worstNote(n+1)))
+	})
+
+	return s.renderMarkdownGroups(reduced[:n], opts, worstNote(n))
+}
+
+// collapseGroup removes the file lines of the group, ensuring that the package
+// line is shown instead.
+func collapseGroup(g rowGroup) rowGroup {
+	g.files = nil
+	g.fileURLs = nil
+	g.showPkg = true
+
+	return g
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+
+	return plural
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestMarkdownMaxSize(t *testing.T) {
+	t.Parallel()
+
+	newPkg := func(name string, hit, total int, files ...string) *Package {
+		pkg := &Package{
+			Stats: types.Stats{
+				NumStatements: total, HitCount: hit,
+				Coverage: float64(hit) / float64(total),
+			},
+			Name:  name,
+			Files: map[string]*File{},
+		}
+		for _, f := range files {
+			pkg.Files[f] = &File{Stats: pkg.Stats, Name: f, Package: name}
+		}
+		return pkg
+	}
+
+	report := &Report{
+		Stats: types.Stats{Coverage: 0.5},
+		Packages: map[string]*Package{
+			"pkg1": newPkg("pkg1", 10, 10, "file1.go", "file2.go", "file3.go", "file4.go", "file5.go", "file6.go"),
+			"pkg2": newPkg("pkg2", 2, 10, "file7.go"),
+			"pkg3": newPkg("pkg3", 5, 10, "file8.go"),
+		},
+	}
+
+	badge := "![Total Coverage](https://img.shields.io/badge/Total%20Coverage-50.00%25-critical?style=flat)\n\n"
+
+	tests := []struct {
+		name      string
+		nestFiles bool
+		maxSize   int
+		want      string
+	}{
+		{
+			name:      "fits",
+			nestFiles: false,
+			maxSize:   10000,
+			want: badge +
+				"| Package         | Coverage |\n" +
+				"| :------         | -------: |\n" +
+				"| `pkg1`          |  100.00% |\n" +
+				"| `pkg1/file1.go` |  100.00% |\n" +
+				"| `pkg1/file2.go` |  100.00% |\n" +
+				"| `pkg1/file3.go` |  100.00% |\n" +
+				"| `pkg1/file4.go` |  100.00% |\n" +
+				"| `pkg1/file5.go` |  100.00% |\n" +
+				"| `pkg1/file6.go` |  100.00% |\n" +
+				"| `pkg2`          |   20.00% |\n" +
+				"| `pkg2/file7.go` |   20.00% |\n" +
+				"| `pkg3`          |   50.00% |\n" +
+				"| `pkg3/file8.go` |   50.00% |",
+		},
+		{
+			name:      "omit_covered_files",
+			nestFiles: false,
+			maxSize:   450,
+			want: badge +
+				"| Package         | Coverage |\n" +
+				"| :------         | -------: |\n" +
+				"| `pkg1`          |  100.00% |\n" +
+				"| `pkg2`          |   20.00% |\n" +
+				"| `pkg2/file7.go` |   20.00% |\n" +
+				"| `pkg3`          |   50.00% |\n" +
+				"| `pkg3/file8.go` |   50.00% |\n\n" +
+				"> **Note**: File details were omitted for 1 fully covered package " +
+				"to keep the report within 450 characters.",
+		},
+		{
+			name:      "omit_all_files",
+			nestFiles: true,
+			maxSize:   400,
+			want: badge +
+				"| Package | Coverage |\n" +
+				"| :------ | -------: |\n" +
+				"| `pkg1`  |  100.00% |\n" +
+				"| `pkg2`  |   20.00% |\n" +
+				"| `pkg3`  |   50.00% |\n\n" +
+				"> **Note**: File details were omitted for all packages " +
+				"to keep the report within 400 characters.",
+		},
+		{
+			name:      "worst_packages",
+			nestFiles: true,
+			maxSize:   304,
+			want: badge +
+				"| Package | Coverage |\n" +
+				"| :------ | -------: |\n" +
+				"| `pkg2`  |   20.00% |\n\n" +
+				"> **Note**: Only the 1 package with the lowest coverage is shown, " +
+				"and 2 were omitted, to keep the report within 304 characters.",
+		},
+		{
+			name:      "omit_all_packages",
+			nestFiles: true,
+			maxSize:   10,
+			want: badge +
+				"> **Note**: All 3 packages were omitted to keep the report " +
+				"within 10 characters.",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := report.Render(Markdown, RenderOptions{
+				NestFiles:  tt.nestFiles,
+				Thresholds: Thresholds{Lower: 70, Upper: 90},
+				MaxSize:    tt.maxSize,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if tt.maxSize > len(badge) {
+				assert.LessOrEqual(t, utf8.RuneCountInString(got), tt.maxSize,
+					fmt.Sprintf("output exceeds %d characters", tt.maxSize))
+			}
+		})
+	}
+}
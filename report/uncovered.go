@@ -0,0 +1,62 @@
+package report
+
+import (
+	"sort"
+)
+
+// Uncovered is a block of code in a file that was never executed.
+type Uncovered struct {
+	// Path is the file path, after applying path remaps.
+	Path string
+	Block
+}
+
+// UncoveredBlocks returns the uncovered blocks of all files that aren't
+// excluded by opts.Filter, sorted by path and position. File paths are
+// converted using opts.PathRemaps.
+func (s *Report) UncoveredBlocks(opts RenderOptions) []Uncovered {
+	var blocks []Uncovered
+	for _, pkg := range s.Packages {
+		for _, file := range pkg.Files {
+			absPath := file.AbsPath()
+			if opts.Filter != nil && opts.Filter.MatchesPath(absPath) {
+				continue
+			}
+			path := opts.PathRemaps.Apply(absPath)
+			for _, b := range file.UncoveredBlocks() {
+				blocks = append(blocks, Uncovered{Path: path, Block: b})
+			}
+		}
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].Path != blocks[j].Path {
+			return blocks[i].Path < blocks[j].Path
+		}
+		return blocks[i].FileBlock.Before(blocks[j].FileBlock)
+	})
+
+	return blocks
+}
+
+// MergeAdjacent merges uncovered blocks of the same file that overlap or are
+// on consecutive lines into a single block. The blocks must be sorted by path
+// and position, as returned by UncoveredBlocks.
+func MergeAdjacent(blocks []Uncovered) []Uncovered {
+	merged := make([]Uncovered, 0, len(blocks))
+	for _, b := range blocks {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Path == b.Path && b.Start.Line <= last.End.Line+1 {
+				if last.End.Before(b.End) {
+					last.End = b.End
+				}
+				last.NumStatements += b.NumStatements
+				continue
+			}
+		}
+		merged = append(merged, b)
+	}
+
+	return merged
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/stretchr/testify/assert"
+
+	"go.hackfix.me/fcov/types"
+)
+
+func TestUncoveredBlocks(t *testing.T) {
+	t.Parallel()
+
+	block := func(startLine, startCol, endLine, endCol, numStmt, hits int) Block {
+		return Block{
+			FileBlock: types.FileBlock{
+				Start: types.FileLocation{Line: startLine, Col: startCol},
+				End:   types.FileLocation{Line: endLine, Col: endCol},
+			},
+			NumStatements: numStmt, HitCount: hits,
+		}
+	}
+
+	report := &Report{
+		Packages: map[string]*Package{
+			"example.com/mod/pkg1": {
+				Name: "example.com/mod/pkg1",
+				Files: map[string]*File{
+					"b.go": {
+						Name: "b.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{
+							block(3, 1, 5, 2, 2, 0),
+							block(5, 2, 7, 10, 1, 0),
+							block(8, 1, 9, 2, 1, 0),
+							block(12, 1, 14, 2, 3, 1),
+							block(20, 1, 22, 2, 1, 0),
+						},
+					},
+					"a.go": {
+						Name: "a.go", Package: "example.com/mod/pkg1",
+						Blocks: []Block{block(1, 1, 2, 2, 1, 0)},
+					},
+				},
+			},
+			"example.com/mod/pkg2": {
+				Name: "example.com/mod/pkg2",
+				Files: map[string]*File{
+					"c.go": {
+						Name: "c.go", Package: "example.com/mod/pkg2",
+						Blocks: []Block{block(1, 1, 2, 2, 1, 0)},
+					},
+				},
+			},
+		},
+	}
+
+	opts := RenderOptions{
+		Filter:     gitignore.CompileIgnoreLines("*/pkg2"),
+		PathRemaps: PathRemaps{{From: "example.com/mod/", To: ""}},
+	}
+	blocks := report.UncoveredBlocks(opts)
+	assert.Equal(t, []Uncovered{
+		{Path: "pkg1/a.go", Block: block(1, 1, 2, 2, 1, 0)},
+		{Path: "pkg1/b.go", Block: block(3, 1, 5, 2, 2, 0)},
+		{Path: "pkg1/b.go", Block: block(5, 2, 7, 10, 1, 0)},
+		{Path: "pkg1/b.go", Block: block(8, 1, 9, 2, 1, 0)},
+		{Path: "pkg1/b.go", Block: block(20, 1, 22, 2, 1, 0)},
+	}, blocks)
+
+	assert.Equal(t, []Uncovered{
+		{Path: "pkg1/a.go", Block: block(1, 1, 2, 2, 1, 0)},
+		{Path: "pkg1/b.go", Block: block(3, 1, 9, 2, 4, 0)},
+		{Path: "pkg1/b.go", Block: block(20, 1, 22, 2, 1, 0)},
+	}, MergeAdjacent(blocks))
+}
@@ -32,6 +32,25 @@ func (fb *FileBlock) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// Before returns true if fb starts before other, or if both start at the
+// same location and fb ends before other.
+func (fb FileBlock) Before(other FileBlock) bool {
+	if fb.Start != other.Start {
+		return fb.Start.Before(other.Start)
+	}
+
+	return fb.End.Before(other.End)
+}
+
+// Before returns true if fl is located before other.
+func (fl FileLocation) Before(other FileLocation) bool {
+	if fl.Line != other.Line {
+		return fl.Line < other.Line
+	}
+
+	return fl.Col < other.Col
+}
+
 // Stats holds coverage related statistics.
 type Stats struct {
 	NumStatements int
@@ -0,0 +1,133 @@
+// Package vcs reads version control information directly from the
+// filesystem, without depending on external tools.
+package vcs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// ErrNotFound is returned if no repository is found.
+var ErrNotFound = errors.New("repository not found")
+
+// Info is the state of a repository's working tree.
+type Info struct {
+	// Commit is the full SHA of the checked out commit.
+	Commit string
+	// Branch is the name of the checked out branch. It is empty if HEAD is
+	// detached.
+	Branch string
+}
+
+// DetectGit finds the Git repository that contains dir, by searching dir and
+// its parent directories, and returns the commit and branch of its HEAD.
+func DetectGit(fs vfs.FileSystem, dir string) (Info, error) {
+	gitDir, err := findGitDir(fs, dir)
+	if err != nil {
+		return Info{}, err
+	}
+
+	head, err := vfs.ReadFile(fs, vfs.Join(fs, gitDir, "HEAD"))
+	if err != nil {
+		return Info{}, fmt.Errorf("failed reading HEAD: %w", err)
+	}
+
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		// Detached HEAD
+		return Info{Commit: ref}, nil
+	}
+
+	info := Info{Branch: strings.TrimPrefix(ref, "refs/heads/")}
+	info.Commit, err = resolveRef(fs, commonDir(fs, gitDir), ref)
+	if err != nil {
+		return Info{}, err
+	}
+
+	return info, nil
+}
+
+// findGitDir returns the path to the .git directory of the repository that
+// contains dir.
+func findGitDir(fs vfs.FileSystem, dir string) (string, error) {
+	dir, err := vfs.Canonical(fs, dir, false)
+	if err != nil {
+		return "", fmt.Errorf("failed resolving directory '%s': %w", dir, err)
+	}
+
+	for {
+		gitPath := vfs.Join(fs, dir, ".git")
+		if ok, _ := vfs.DirExists(fs, gitPath); ok {
+			return gitPath, nil
+		}
+		if ok, _ := vfs.FileExists(fs, gitPath); ok {
+			// Worktrees and submodules have a .git file that points to the
+			// actual Git directory.
+			data, err := vfs.ReadFile(fs, gitPath)
+			if err != nil {
+				return "", fmt.Errorf("failed reading '%s': %w", gitPath, err)
+			}
+			gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+			if !ok {
+				return "", fmt.Errorf("invalid .git file '%s'", gitPath)
+			}
+			if !vfs.IsAbs(fs, gitDir) {
+				gitDir = vfs.Join(fs, dir, gitDir)
+			}
+			return gitDir, nil
+		}
+
+		parent := vfs.Dir(fs, dir)
+		if parent == dir {
+			return "", ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// commonDir returns the directory that holds the refs shared by all
+// worktrees, which is the Git directory itself for the main worktree.
+func commonDir(fs vfs.FileSystem, gitDir string) string {
+	data, err := vfs.ReadFile(fs, vfs.Join(fs, gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := strings.TrimSpace(string(data))
+	if !vfs.IsAbs(fs, dir) {
+		dir = vfs.Join(fs, gitDir, dir)
+	}
+
+	return dir
+}
+
+// resolveRef returns the commit SHA ref points to, reading it from either a
+// loose ref file or the packed-refs file.
+func resolveRef(fs vfs.FileSystem, gitDir, ref string) (string, error) {
+	if data, err := vfs.ReadFile(fs, vfs.Join(fs, gitDir, ref)); err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	f, err := fs.Open(vfs.Join(fs, gitDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("failed resolving ref '%s': %w", ref, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sha, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref {
+			return sha, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed reading packed-refs: %w", err)
+	}
+
+	// A branch without any commits.
+	return "", nil
+}
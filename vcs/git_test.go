@@ -0,0 +1,100 @@
+package vcs
+
+import (
+	"testing"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectGit(t *testing.T) {
+	t.Parallel()
+
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		dir     string
+		expInfo Info
+		expErr  string
+	}{
+		{
+			name: "ok/loose_ref",
+			files: map[string]string{
+				"/repo/.git/HEAD":            "ref: refs/heads/main\n",
+				"/repo/.git/refs/heads/main": sha + "\n",
+			},
+			dir:     "/repo",
+			expInfo: Info{Commit: sha, Branch: "main"},
+		},
+		{
+			name: "ok/packed_ref_subdir",
+			files: map[string]string{
+				"/repo/.git/HEAD":        "ref: refs/heads/feature/x\n",
+				"/repo/.git/packed-refs": "# pack-refs with: peeled fully-peeled sorted\n" + sha + " refs/heads/feature/x\n",
+				"/repo/pkg/sub/file.go":  "",
+			},
+			dir:     "/repo/pkg/sub",
+			expInfo: Info{Commit: sha, Branch: "feature/x"},
+		},
+		{
+			name: "ok/detached",
+			files: map[string]string{
+				"/repo/.git/HEAD": sha + "\n",
+			},
+			dir:     "/repo",
+			expInfo: Info{Commit: sha},
+		},
+		{
+			name: "ok/worktree",
+			files: map[string]string{
+				"/wt/.git":                                "gitdir: /repo/.git/worktrees/wt\n",
+				"/repo/.git/worktrees/wt/HEAD":            "ref: refs/heads/wt\n",
+				"/repo/.git/worktrees/wt/commondir":       "../..\n",
+				"/repo/.git/refs/heads/wt":                sha + "\n",
+				"/repo/.git/worktrees/wt/refs/heads/.tmp": "",
+			},
+			dir:     "/wt",
+			expInfo: Info{Commit: sha, Branch: "wt"},
+		},
+		{
+			name:   "err/not_found",
+			files:  map[string]string{"/other/file": ""},
+			dir:    "/other",
+			expErr: "repository not found",
+		},
+		{
+			name: "err/unresolved_ref",
+			files: map[string]string{
+				"/repo/.git/HEAD": "ref: refs/heads/main\n",
+			},
+			dir:    "/repo",
+			expErr: "failed resolving ref 'refs/heads/main'",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := memoryfs.New()
+			for fpath, content := range tc.files {
+				require.NoError(t, fs.MkdirAll(vfs.Dir(fs, fpath), 0o755))
+				require.NoError(t, vfs.WriteFile(fs, fpath, []byte(content), 0o644))
+			}
+
+			info, err := DetectGit(fs, tc.dir)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expInfo, info)
+		})
+	}
+}